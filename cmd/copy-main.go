@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+// copyCmd represents the 'copy' command
+var copyCmd = &cobra.Command{
+	Use:   "copy <source> <destination>",
+	Short: "Copy configuration(s) to another path",
+	Args:  cobra.ExactArgs(2), //nolint:gomnd
+	RunE:  runCopy,
+}
+
+// copyOptions holds flags shared by 'copy' and 'move' commands.
+type copyOptions struct {
+	Recursive    bool
+	Overwrite    bool
+	SkipExisting bool
+	DryRun       bool
+}
+
+var copyParameters copyOptions
+
+func init() {
+	addCopyFlags(copyCmd, &copyParameters)
+	// add 'copy' command to root command
+	rootCmd.AddCommand(copyCmd)
+}
+
+//nolint:lll
+func addCopyFlags(cmd *cobra.Command, options *copyOptions) {
+	cmd.Flags().BoolVar(&options.Recursive, "recursive", false, "Process all configurations under the source prefix")
+	cmd.Flags().BoolVar(&options.Overwrite, "overwrite", false, "Overwrite existing destination configurations")
+	cmd.Flags().BoolVar(&options.SkipExisting, "skip-existing", false, "Skip configurations which already exist at destination")
+	cmd.Flags().BoolVar(&options.DryRun, "dryrun", false, "Display result of operation without actually changing configurations")
+}
+
+func runCopy(cmd *cobra.Command, args []string) error {
+	return runCopyOrMove(args, copyParameters, false)
+}
+
+// copyItem is a single configuration to copy.
+type copyItem struct {
+	Source      string
+	Destination string
+	Value       store.Value
+}
+
+//nolint:funlen
+func runCopyOrMove(args []string, options copyOptions, move bool) error {
+	sourcePath := path.Join(pathSeparator, args[0])
+	destinationPath := path.Join(pathSeparator, args[1])
+
+	for _, p := range []string{sourcePath, destinationPath} {
+		if err := validateConfigPathName(p); err != nil {
+			return fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	if options.Overwrite && options.SkipExisting {
+		return errors.New("--overwrite and --skip-existing can not be used together")
+	}
+
+	if err := checkCopyPaths(sourcePath, destinationPath, options.Recursive); err != nil {
+		return err
+	}
+
+	configStore, err := getConfigurationStore()
+	if err != nil {
+		return fmt.Errorf("failed to get configuration store: %w", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	items, err := copyItems(ctx, configStore, sourcePath, destinationPath, options.Recursive)
+	if err != nil {
+		return err
+	}
+
+	// check destinations before writing anything
+	pending := make([]copyItem, 0, len(items))
+
+	for _, item := range items {
+		_, err := getFromStore(ctx, configStore, item.Destination)
+
+		switch {
+		case errors.Is(err, store.ErrConfigNotFound):
+			pending = append(pending, item)
+		case err != nil:
+			return fmt.Errorf("failed to fetch configuration `%s`: %w", item.Destination, err)
+		case options.SkipExisting:
+			fmt.Printf("Skipping `%s`, `%s` already exists\n", item.Source, item.Destination)
+		case options.Overwrite:
+			pending = append(pending, item)
+		default:
+			return fmt.Errorf("configuration `%s` already exists (use --overwrite or --skip-existing)", item.Destination)
+		}
+	}
+
+	verb := "Copying"
+	if move {
+		verb = "Moving"
+	}
+
+	for _, item := range pending {
+		fmt.Printf("%s `%s` to `%s`\n", verb, item.Source, item.Destination)
+
+		if options.DryRun {
+			continue
+		}
+
+		if err := configStore.Put(ctx, parameterNameFromPath(item.Destination), item.Value); err != nil {
+			return fmt.Errorf("failed to write configuration `%s`: %w", item.Destination, err)
+		}
+	}
+
+	if !move || options.DryRun {
+		return nil
+	}
+
+	// remove sources only after all writes succeeded
+	for _, item := range pending {
+		if err := deleteFromStore(ctx, configStore, item.Source); err != nil {
+			return fmt.Errorf("failed to delete configuration `%s`: %w", item.Source, err)
+		}
+	}
+
+	return nil
+}
+
+// checkCopyPaths rejects copying configuration onto itself, which would lose
+// data when moving. In recursive mode prefixes must not contain each other.
+func checkCopyPaths(sourcePath, destinationPath string, recursive bool) error {
+	if sourcePath == destinationPath {
+		return fmt.Errorf("source and destination are the same `%s`", sourcePath)
+	}
+
+	if recursive && (store.HasPathPrefix(destinationPath, sourcePath) || store.HasPathPrefix(sourcePath, destinationPath)) {
+		return fmt.Errorf("source `%s` and destination `%s` prefixes overlap", sourcePath, destinationPath)
+	}
+
+	return nil
+}
+
+// copyItems collects configurations to copy from the source to the
+// destination, either a single key or all keys under the source prefix.
+// Values keep their secure flag, description and KMS key.
+func copyItems(ctx context.Context, configStore store.Store, sourcePath, destinationPath string, recursive bool) ([]copyItem, error) {
+	var configs []store.Value
+
+	if recursive {
+		var err error
+
+		configs, err = configStore.List(ctx, sourcePath, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list store contents (%s): %w", sourcePath, err)
+		}
+	} else {
+		config, err := getFromStore(ctx, configStore, sourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch configuration `%s`: %w", sourcePath, err)
+		}
+
+		configs = []store.Value{config}
+	}
+
+	items := make([]copyItem, 0, len(configs))
+
+	for _, config := range configs {
+		destination := destinationPath
+		if recursive {
+			destination = path.Join(destinationPath, stripPrefix(config.Meta.Key, sourcePath))
+		}
+
+		items = append(items, copyItem{
+			Source:      config.Meta.Key,
+			Destination: destination,
+			Value: store.Value{
+				Value: config.Value,
+				Meta: store.Metadata{
+					Description: config.Meta.Description,
+					Secure:      config.Meta.Secure,
+					KeyID:       config.Meta.KeyID,
+				},
+			},
+		})
+	}
+
+	return items, nil
+}
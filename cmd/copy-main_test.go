@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+func TestCopyItems(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemoryStore()
+
+	password := "pass"
+	err := s.Put(ctx, store.ParameterName{ParameterPath: "/staging/db", Name: "password"},
+		store.Value{Value: &password, Meta: store.Metadata{Secure: true, KeyID: "alias/staging"}})
+	assert.Nil(t, err)
+
+	user := "admin"
+	err = s.Put(ctx, store.ParameterName{ParameterPath: "/staging/db", Name: "user"}, store.Value{Value: &user})
+	assert.Nil(t, err)
+
+	items, err := copyItems(ctx, s, "/staging", "/prod", true)
+	assert.Nil(t, err)
+	assert.Len(t, items, 2)
+
+	assert.Equal(t, "/staging/db/password", items[0].Source)
+	assert.Equal(t, "/prod/db/password", items[0].Destination)
+	assert.True(t, items[0].Value.Meta.Secure)
+	assert.Equal(t, "alias/staging", items[0].Value.Meta.KeyID)
+	assert.Equal(t, "/prod/db/user", items[1].Destination)
+
+	items, err = copyItems(ctx, s, "/staging/db/user", "/prod/db/username", false)
+	assert.Nil(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "/prod/db/username", items[0].Destination)
+
+	_, err = copyItems(ctx, s, "/staging/db/missing", "/prod/db/missing", false)
+	assert.Error(t, err)
+}
+
+func TestCheckCopyPaths(t *testing.T) {
+	assert.Error(t, checkCopyPaths("/a/x", "/a/x", false))
+	assert.Error(t, checkCopyPaths("/a/x", "/a/x", true))
+	assert.Nil(t, checkCopyPaths("/a/x", "/a/x/y", false))
+	assert.Error(t, checkCopyPaths("/a", "/a/x", true))
+	assert.Error(t, checkCopyPaths("/a/x", "/a", true))
+	assert.Error(t, checkCopyPaths("/", "/a", true))
+	assert.Nil(t, checkCopyPaths("/a/x", "/a/xy", true))
+	assert.Nil(t, checkCopyPaths("/staging", "/prod", true))
+}
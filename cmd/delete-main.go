@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path"
@@ -46,9 +47,12 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get configuration store: %w", err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	// check whether to delete single configuration
 
-	_, err = getFromStore(configStore, configPathName)
+	_, err = getFromStore(ctx, configStore, configPathName)
 	if err != nil {
 		if !errors.Is(err, store.ErrConfigNotFound) {
 			return fmt.Errorf("failed to fetch configuration: %w", err)
@@ -57,7 +61,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Removing `%s`\n", configPathName)
 
 		if !deleteParameters.DryRun {
-			err = deleteFromStore(configStore, configPathName)
+			err = deleteFromStore(ctx, configStore, configPathName)
 			if err != nil {
 				return fmt.Errorf("failed to delete configuration `%s`: %w", configPathName, err)
 			}
@@ -76,7 +80,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return errors.New(ErrRecursiveDeleteOp)
 	}
 
-	configs, err := configStore.List(configPathName, false)
+	configs, err := configStore.List(ctx, configPathName, false)
 	if err != nil {
 		return fmt.Errorf("failed to list store contents (%s): %w", configPathName, err)
 	}
@@ -88,7 +92,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 
 		if !deleteParameters.DryRun {
 			// actually delete configuration
-			err = deleteFromStore(configStore, key)
+			err = deleteFromStore(ctx, configStore, key)
 			if err != nil {
 				return fmt.Errorf("failed to delete configuration `%s`: %w", key, err)
 			}
@@ -107,12 +111,12 @@ func parameterNameFromPath(configPath string) store.ParameterName {
 	}
 }
 
-func getFromStore(configStore store.Store, configPath string) (store.Value, error) {
+func getFromStore(ctx context.Context, configStore store.Store, configPath string) (store.Value, error) {
 	parameterName := parameterNameFromPath(configPath)
-	return configStore.Get(parameterName, -1)
+	return configStore.Get(ctx, parameterName, -1)
 }
 
-func deleteFromStore(configStore store.Store, configPath string) error {
+func deleteFromStore(ctx context.Context, configStore store.Store, configPath string) error {
 	parameterName := parameterNameFromPath(configPath)
-	return configStore.Delete(parameterName)
+	return configStore.Delete(ctx, parameterName)
 }
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+// diffCmd represents the 'diff' command
+var diffCmd = &cobra.Command{
+	Use:   "diff <prefix> [<other-prefix>]",
+	Short: "Compare the configurations under two prefixes",
+	Long: `Compare the configurations under two prefixes.
+
+Keys are compared relative to their prefix. The other prefix defaults to the
+first one, and is read from the store selected with --to-backend, --to-file
+and --to-profile, which default to the global flags. Keys only under the
+first prefix are shown with '-', keys only under the other prefix with '+',
+and keys with different values with '~'.`,
+	Args: cobra.RangeArgs(1, 2), //nolint:gomnd
+	RunE: runDiff,
+}
+
+var diffParameters struct {
+	ShowValues bool
+	Target     backendConfig
+}
+
+//nolint:lll
+func init() {
+	diffCmd.Flags().BoolVar(&diffParameters.ShowValues, "show-values", false, "Show values of the configurations (masked by default)")
+	addTargetBackendFlags(diffCmd, &diffParameters.Target)
+	// add 'diff' command to root command
+	rootCmd.AddCommand(diffCmd)
+}
+
+// configDiff holds the differences between current and desired
+// configurations, keyed by names relative to their prefix.
+type configDiff struct {
+	Added     []string
+	Removed   []string
+	Changed   []string
+	Unchanged []string
+}
+
+func (d configDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// diffConfigs compares configurations, both maps are keyed by names relative
+// to their prefix.
+func diffConfigs(current, desired map[string]string) configDiff {
+	var diff configDiff
+
+	for _, k := range sortedKeys(desired) {
+		v, ok := current[k]
+
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, k)
+		case v != desired[k]:
+			diff.Changed = append(diff.Changed, k)
+		default:
+			diff.Unchanged = append(diff.Unchanged, k)
+		}
+	}
+
+	for _, k := range sortedKeys(current) {
+		if _, ok := desired[k]; !ok {
+			diff.Removed = append(diff.Removed, k)
+		}
+	}
+
+	return diff
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	prefixPath := path.Join(pathSeparator, args[0])
+	otherPrefixPath := prefixPath
+
+	if len(args) > 1 {
+		otherPrefixPath = path.Join(pathSeparator, args[1])
+	}
+
+	for _, p := range []string{prefixPath, otherPrefixPath} {
+		if err := validateConfigPathName(p); err != nil {
+			return fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	configStore, err := getConfigurationStore()
+	if err != nil {
+		return fmt.Errorf("failed to get configuration store: %w", err)
+	}
+
+	otherStore, err := getTargetConfigurationStore(diffParameters.Target)
+	if err != nil {
+		return fmt.Errorf("failed to get target configuration store: %w", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	current, err := listRelative(ctx, configStore, prefixPath)
+	if err != nil {
+		return err
+	}
+
+	desired, err := listRelative(ctx, otherStore, otherPrefixPath)
+	if err != nil {
+		return err
+	}
+
+	printConfigDiff(os.Stdout, diffConfigs(current, desired), current, desired, diffParameters.ShowValues)
+
+	return nil
+}
+
+// listRelative lists configuration values under the prefix, keyed by names
+// relative to the prefix.
+func listRelative(ctx context.Context, configStore store.Store, prefixPath string) (map[string]string, error) {
+	rawValues, err := configStore.ListRaw(ctx, prefixPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list store contents (%s): %w", prefixPath, err)
+	}
+
+	params := make(map[string]string, len(rawValues))
+
+	for _, rawValue := range rawValues {
+		params[stripPrefix(rawValue.Key, prefixPath)] = rawValue.Value
+	}
+
+	return params, nil
+}
+
+func printConfigDiff(w io.Writer, diff configDiff, current, desired map[string]string, showValues bool) {
+	value := func(v string) string {
+		if showValues {
+			return fmt.Sprintf("%q", v)
+		}
+
+		return "*****"
+	}
+
+	for _, k := range diff.Removed {
+		fmt.Fprintf(w, "- %s = %s\n", k, value(current[k]))
+	}
+
+	for _, k := range diff.Added {
+		fmt.Fprintf(w, "+ %s = %s\n", k, value(desired[k]))
+	}
+
+	for _, k := range diff.Changed {
+		fmt.Fprintf(w, "~ %s = %s => %s\n", k, value(current[k]), value(desired[k]))
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	current := map[string]string{"db/user": "admin", "db/password": "pass1", "old": "x"}
+	desired := map[string]string{"db/user": "admin", "db/password": "pass2", "new": "y"}
+
+	diff := diffConfigs(current, desired)
+
+	assert.Equal(t, []string{"new"}, diff.Added)
+	assert.Equal(t, []string{"old"}, diff.Removed)
+	assert.Equal(t, []string{"db/password"}, diff.Changed)
+	assert.Equal(t, []string{"db/user"}, diff.Unchanged)
+	assert.False(t, diff.empty())
+
+	var buf bytes.Buffer
+
+	printConfigDiff(&buf, diff, current, desired, false)
+	assert.Equal(t, "- old = *****\n+ new = *****\n~ db/password = ***** => *****\n", buf.String())
+
+	buf.Reset()
+
+	printConfigDiff(&buf, diff, current, desired, true)
+	assert.Contains(t, buf.String(), `~ db/password = "pass1" => "pass2"`)
+
+	assert.True(t, diffConfigs(current, current).empty())
+}
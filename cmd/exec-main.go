@@ -11,6 +11,7 @@ import (
 
 	"github.com/zbiljic/sicc/pkg/environ"
 	"github.com/zbiljic/sicc/pkg/exec"
+	"github.com/zbiljic/sicc/store"
 )
 
 const (
@@ -20,7 +21,7 @@ const (
 
 // execCmd represents the 'exec' command
 var execCmd = &cobra.Command{
-	Use:     "exec <prefix...> -- <command> [<arg...>]",
+	Use:     "exec [<prefix...>] -- <command> [<arg...>]",
 	Short:   "Executes a command with configurations loaded into the environment",
 	Args:    cobra.MinimumNArgs(1), //nolint:gomnd
 	PreRunE: checkExecSyntax,
@@ -42,6 +43,14 @@ Given a configuration store like this:
 	$ HOME=/tmp DB_USERNAME=changeme DB_PASSWORD=changeme sicc exec --strict --pristine /prod exec -- env
 	DB_USERNAME=admin
 	DB_PASSWORD=pass
+
+--resolve-references replaces env vars referencing configurations with their
+values, also without any prefix
+
+	$ HOME=/tmp DB_PASSWORD=sicc:///prod/db/password OLD_PASSWORD=sicc:///prod/db/password#version=1 sicc exec --resolve-references -- env
+	HOME=/tmp
+	DB_PASSWORD=pass
+	OLD_PASSWORD=pass
 `,
 }
 
@@ -56,6 +65,16 @@ var execParameters struct {
 
 	// Value to expect in strict mode
 	StrictValue string
+
+	// When true, references to other configurations in values are expanded
+	Expand bool
+
+	// When true, env vars referencing configurations, like
+	// sicc:///prod/db/password, are replaced with their values
+	ResolveReferences bool
+
+	// Mapping of configuration keys to env var names
+	EnvNames envNameOptions
 }
 
 //nolint:lll
@@ -68,6 +87,11 @@ env var with value <strict-value>, and fail if there are any env vars with
 that value missing from secrets`)
 	execCmd.Flags().StringVar(&execParameters.StrictValue, "strict-value", strictValueDefault,
 		"Value to expect in --strict mode")
+	execCmd.Flags().BoolVar(&execParameters.Expand, "expand", false,
+		"Expand references to other configurations in values, like ${/prod/db/host}")
+	execCmd.Flags().BoolVar(&execParameters.ResolveReferences, "resolve-references", false,
+		"Replace env vars referencing configurations, like sicc:///prod/db/password, with their values")
+	addEnvNameFlags(execCmd, &execParameters.EnvNames)
 	// add 'exec' command to root command
 	rootCmd.AddCommand(execCmd)
 }
@@ -80,11 +104,6 @@ func checkExecSyntax(cmd *cobra.Command, args []string) error {
 		return errors.New("please separate prefix and command with '--'. See usage")
 	}
 
-	//nolint:gomnd
-	if err := cobra.MinimumNArgs(1)(cmd, args[:dashIx]); err != nil {
-		return fmt.Errorf("at least one prefix must be specified: %w. See usage", err)
-	}
-
 	//nolint:gomnd
 	if err := cobra.MinimumNArgs(1)(cmd, args[dashIx:]); err != nil {
 		return fmt.Errorf("must specify command to run: %w. See usage", err)
@@ -93,6 +112,7 @@ func checkExecSyntax(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+//nolint:funlen
 func runExec(cmd *cobra.Command, args []string) error {
 	dashIx := cmd.ArgsLenAtDash()
 	prefixPaths, command, commandArgs := args[:dashIx], args[dashIx], args[dashIx+1:]
@@ -105,15 +125,45 @@ func runExec(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	mapper, err := execParameters.EnvNames.mapper()
+	if err != nil {
+		return err
+	}
+
 	configStore, err := getConfigurationStore()
 	if err != nil {
 		return fmt.Errorf("failed to get configuration store: %w", err)
 	}
 
+	if execParameters.Expand {
+		configStore = store.NewExpandingStore(configStore)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	if execParameters.Pristine && globalVerbose {
 		fmt.Fprintf(os.Stderr, "%s: pristine mode engaged\n", AppName)
 	}
 
+	// env vars referencing configurations are kept in pristine mode, as their
+	// values are retrieved from the backend
+	parent := environ.Environ(os.Environ())
+	resolved := map[string]string{}
+
+	if execParameters.ResolveReferences {
+		references, err := parent.LoadReferences(ctx, configStore)
+		if err != nil {
+			return err
+		}
+
+		parentMap := parent.Map()
+
+		for _, k := range references {
+			resolved[k] = parentMap[k]
+		}
+	}
+
 	var env environ.Environ
 
 	if execParameters.Strict {
@@ -121,21 +171,28 @@ func runExec(cmd *cobra.Command, args []string) error {
 			fmt.Fprintf(os.Stderr, "%s: strict mode engaged\n", AppName)
 		}
 
-		env = environ.Environ(os.Environ())
+		env = parent
 
-		err := env.LoadStrict(configStore, execParameters.StrictValue, execParameters.Pristine, prefixPaths...)
+		err := env.LoadStrict(ctx, configStore, mapper, execParameters.StrictValue, execParameters.Pristine, prefixPaths...)
 		if err != nil {
 			return err
 		}
+
+		if execParameters.Pristine {
+			keepReferences(&env, resolved)
+		}
 	} else {
 		if !execParameters.Pristine {
-			env = environ.Environ(os.Environ())
+			env = parent
+		} else {
+			keepReferences(&env, resolved)
 		}
 
 		for _, prefixPath := range prefixPaths {
 			collisions := make([]string, 0)
+			skipped := make([]environ.InvalidNameError, 0)
 
-			err := env.Load(configStore, prefixPath, &collisions)
+			err := env.Load(ctx, configStore, mapper, prefixPath, &collisions, &skipped)
 			if err != nil {
 				return fmt.Errorf("failed to list store contents: %w", err)
 			}
@@ -143,6 +200,10 @@ func runExec(cmd *cobra.Command, args []string) error {
 			for _, c := range collisions {
 				fmt.Fprintf(os.Stderr, "warning: configuration %s overwriting environment variable %s\n", prefixPath, c)
 			}
+
+			for _, s := range skipped {
+				fmt.Fprintf(os.Stderr, "warning: skipping configuration %s: %v\n", prefixPath, s)
+			}
 		}
 	}
 
@@ -152,3 +213,53 @@ func runExec(cmd *cobra.Command, args []string) error {
 
 	return exec.Exec(command, commandArgs, env)
 }
+
+// envNameOptions holds flags mapping configuration keys to env var names,
+// shared by 'exec' and 'export' commands.
+type envNameOptions struct {
+	Mappings map[string]string
+	Prefix   string
+	KeepCase bool
+	Template string
+}
+
+//nolint:lll
+func addEnvNameFlags(cmd *cobra.Command, options *envNameOptions) {
+	cmd.Flags().StringToStringVar(&options.Mappings, "map", map[string]string{}, "Env var names of configuration keys relative to the prefix, e.g. db/password=DATABASE_PASSWORD")
+	cmd.Flags().StringVar(&options.Prefix, "env-prefix", "", "Prefix of env var names which are not mapped with --map")
+	cmd.Flags().BoolVar(&options.KeepCase, "keep-case", false, "Keep case of configuration keys in env var names")
+	cmd.Flags().StringVar(&options.Template, "name-template", "", "Go template generating env var names which are not mapped with --map, from {{.Key}} and {{.Name}}, with upper, lower and replace functions")
+}
+
+func (o envNameOptions) mapper() (environ.NameMapper, error) {
+	mapper := environ.NameMapper{
+		Mappings: make(map[string]string, len(o.Mappings)),
+		Prefix:   o.Prefix,
+		KeepCase: o.KeepCase,
+	}
+
+	for k, v := range o.Mappings {
+		mapper.Mappings[strings.Trim(k, pathSeparator)] = v
+	}
+
+	if o.Template != "" {
+		tmpl, err := environ.NewNameTemplate(o.Template)
+		if err != nil {
+			return environ.NameMapper{}, err
+		}
+
+		mapper.Template = tmpl
+	}
+
+	return mapper, nil
+}
+
+// keepReferences sets env vars resolved from references in the parent
+// environment, unless already set.
+func keepReferences(env *environ.Environ, resolved map[string]string) {
+	for k, v := range resolved {
+		if !env.IsSet(k) {
+			env.Set(k, v)
+		}
+	}
+}
@@ -2,16 +2,24 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"encoding/base64"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"regexp"
 	"strings"
+	"text/template"
 
 	"github.com/Jeffail/gabs/v2"
 	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/sicc/pkg/environ"
+	"github.com/zbiljic/sicc/store"
 )
 
 // exportCmd represents the 'export' command
@@ -25,12 +33,30 @@ var exportCmd = &cobra.Command{
 var exportParameters struct {
 	Format string
 	Output string
+	Typed  bool
+	Expand bool
+
+	Template string
+	EnvNames envNameOptions
+
+	Name       string
+	Namespace  string
+	Labels     map[string]string
+	StringData bool
 }
 
 //nolint:lll
 func init() {
-	exportCmd.Flags().StringVarP(&exportParameters.Format, "format", "f", "json", "Output format (json, yaml, csv, tsv, dotenv, tfvars, tfenvvars)")
+	exportCmd.Flags().StringVarP(&exportParameters.Format, "format", "f", "json", "Output format (json, yaml, csv, tsv, dotenv, tfvars, tfenvvars, shell, fish, powershell, docker-env, k8s-secret, k8s-configmap)")
+	exportCmd.Flags().BoolVar(&exportParameters.Typed, "typed", false, "For json and yaml, emit values imported with --typed as numbers, booleans and arrays (their type is recorded in the description). Numbers are emitted as written in json, while yaml may reformat them, e.g. 1.0 as 1")
+	exportCmd.Flags().BoolVar(&exportParameters.Expand, "expand", false, "Expand references to other configurations, like ${/prod/db/host}")
+	exportCmd.Flags().StringVar(&exportParameters.Template, "template", "", "Render the Go template file instead of using the format (see 'render' command for available functions)")
 	exportCmd.Flags().StringVarP(&exportParameters.Output, "output-file", "o", "", "Output file (default is standard output)")
+	addEnvNameFlags(exportCmd, &exportParameters.EnvNames)
+	exportCmd.Flags().StringVar(&exportParameters.Name, "name", "", "For k8s formats, the name of the resource (default is derived from the first prefix)")
+	exportCmd.Flags().StringVar(&exportParameters.Namespace, "namespace", "", "For k8s formats, the namespace of the resource")
+	exportCmd.Flags().StringToStringVar(&exportParameters.Labels, "labels", map[string]string{}, "For k8s formats, the labels of the resource, e.g. app=api,tier=backend")
+	exportCmd.Flags().BoolVar(&exportParameters.StringData, "string-data", false, "For k8s-secret format, emit values as plain text stringData instead of base64 encoded data")
 	// add 'export' command to root command
 	rootCmd.AddCommand(exportCmd)
 }
@@ -44,27 +70,41 @@ func runExport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get configuration store: %w", err)
 	}
 
-	params := make(map[string]string)
+	if exportParameters.Typed && !store.KeepsDescription(configStore) {
+		return errTypedWithoutDescriptions
+	}
+
+	if exportParameters.Expand {
+		configStore = store.NewExpandingStore(configStore)
+	}
 
-	for _, arg := range args {
-		prefixPath := path.Join("/", arg)
+	ctx, cancel := commandContext()
+	defer cancel()
 
-		if err := validateConfigPathName(prefixPath); err != nil {
-			return fmt.Errorf("validation failed: %w", err)
-		}
+	mapper, err := exportParameters.EnvNames.mapper()
+	if err != nil {
+		return err
+	}
 
-		rawValues, err := configStore.ListRaw(prefixPath)
-		if err != nil {
-			return fmt.Errorf("failed to list store contents (%s): %w", prefixPath, err)
+	params, err := listParams(ctx, configStore, args)
+	if err != nil {
+		return err
+	}
+
+	// types of values imported in typed mode
+	var types map[string]string
+
+	if exportParameters.Typed {
+		if types, err = listParamTypes(ctx, configStore, args); err != nil {
+			return err
 		}
+	}
 
-		for _, rawValue := range rawValues {
-			k := stripPrefix(rawValue.Key, prefixPath)
-			if _, ok := params[k]; ok {
-				fmt.Fprintf(os.Stderr, "warning: parameter %s specified more than once (overridden by prefix %s)\n", k, prefixPath)
-			}
+	var tmpl *template.Template
 
-			params[k] = rawValue.Value
+	if exportParameters.Template != "" {
+		if tmpl, err = parseTemplateFile(ctx, configStore, params, exportParameters.Template); err != nil {
+			return err
 		}
 	}
 
@@ -85,21 +125,44 @@ func runExport(cmd *cobra.Command, args []string) error {
 	w := bufio.NewWriter(file)
 	defer w.Flush()
 
-	switch strings.ToLower(exportParameters.Format) {
+	format := strings.ToLower(exportParameters.Format)
+	if tmpl != nil {
+		format = "template"
+	}
+
+	switch format {
+	case "template":
+		err = tmpl.Execute(w, params)
 	case "json":
-		err = exportAsJSON(params, w)
+		err = exportAsJSON(params, types, w)
 	case "yaml":
-		err = exportAsYaml(params, w)
+		err = exportAsYaml(params, types, w)
 	case "csv":
 		err = exportAsCsv(params, w)
 	case "tsv":
 		err = exportAsTsv(params, w)
 	case "dotenv":
-		err = exportAsEnvFile(params, w)
+		err = exportAsEnvFile(params, mapper, w)
 	case "tfvars":
 		err = exportAsTfvars(params, w)
 	case "tfenvvars":
-		err = exportAsTfEnvVars(params, w)
+		err = exportAsTfEnvVars(params, mapper, w)
+	case "shell":
+		err = exportAsShell(params, mapper, w)
+	case "fish":
+		err = exportAsFish(params, mapper, w)
+	case "powershell":
+		err = exportAsPowerShell(params, mapper, w)
+	case "docker-env":
+		err = exportAsDockerEnv(params, mapper, w)
+	case "k8s-secret", "k8s-configmap":
+		err = exportAsK8sManifest(params, k8sManifestOptions{
+			Kind:       strings.TrimPrefix(strings.ToLower(exportParameters.Format), "k8s-"),
+			Name:       k8sResourceName(exportParameters.Name, args[0]),
+			Namespace:  exportParameters.Namespace,
+			Labels:     exportParameters.Labels,
+			StringData: exportParameters.StringData,
+		}, w)
 	default:
 		err = fmt.Errorf("unsupported export format: %s", exportParameters.Format)
 	}
@@ -111,37 +174,96 @@ func runExport(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func exportAsJSON(params map[string]string, w io.Writer) error {
-	// JSON like:
-	// {"root":{"param1": "value1","param2": "value2"}}
+// listParams lists configurations under all prefixes, keyed by names relative
+// to the prefix. Configurations from later prefixes override earlier ones.
+func listParams(ctx context.Context, configStore store.Store, prefixes []string) (map[string]string, error) {
+	params := make(map[string]string)
+
+	for _, prefix := range prefixes {
+		prefixPath := path.Join(pathSeparator, prefix)
+
+		if err := validateConfigPathName(prefixPath); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+
+		rawValues, err := configStore.ListRaw(ctx, prefixPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list store contents (%s): %w", prefixPath, err)
+		}
+
+		for _, rawValue := range rawValues {
+			k := stripPrefix(rawValue.Key, prefixPath)
+			if _, ok := params[k]; ok {
+				fmt.Fprintf(os.Stderr, "warning: parameter %s specified more than once (overridden by prefix %s)\n", k, prefixPath)
+			}
+
+			params[k] = rawValue.Value
+		}
+	}
+
+	return params, nil
+}
+
+// listParamTypes lists types of configurations imported in typed mode under
+// all prefixes, keyed like listParams.
+func listParamTypes(ctx context.Context, configStore store.Store, prefixes []string) (map[string]string, error) {
+	types := make(map[string]string)
+
+	for _, prefix := range prefixes {
+		prefixPath := path.Join(pathSeparator, prefix)
+
+		configs, err := configStore.List(ctx, prefixPath, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list store contents (%s): %w", prefixPath, err)
+		}
+
+		for _, config := range configs {
+			types[stripPrefix(config.Meta.Key, prefixPath)] = typeFromDescription(config.Meta.Description)
+		}
+	}
+
+	return types, nil
+}
+
+// exportObject builds nested object from the parameters. Values with type are
+// decoded with decodeTypedValue.
+func exportObject(params map[string]string, types map[string]string) (*gabs.Container, error) {
 	jsonObj := gabs.New()
 
 	for k, v := range params {
 		hierarchy := strings.Split(k, pathSeparator)
 
-		if _, err := jsonObj.Set(v, hierarchy...); err != nil {
-			return fmt.Errorf("failed to set key %s to JSON: %w", k, err)
+		value := decodeTypedValue(v, types[k])
+
+		if _, err := jsonObj.Set(value, hierarchy...); err != nil {
+			return nil, fmt.Errorf("failed to set key %s to JSON: %w", k, err)
 		}
 	}
 
+	return jsonObj, nil
+}
+
+func exportAsJSON(params map[string]string, types map[string]string, w io.Writer) error {
+	// JSON like:
+	// {"root":{"param1": "value1","param2": "value2"}}
+	jsonObj, err := exportObject(params, types)
+	if err != nil {
+		return err
+	}
+
 	fmt.Fprintln(w, jsonObj.String())
 
 	return nil
 }
 
-func exportAsYaml(params map[string]string, w io.Writer) error {
+func exportAsYaml(params map[string]string, types map[string]string, w io.Writer) error {
 	// YAML like:
 	// root:
 	//   param1: "value1"
 	//   param2: "value2"
-	jsonObj := gabs.New()
-
-	for k, v := range params {
-		hierarchy := strings.Split(k, pathSeparator)
-
-		if _, err := jsonObj.Set(v, hierarchy...); err != nil {
-			return fmt.Errorf("failed to set key %s to JSON: %w", k, err)
-		}
+	jsonObj, err := exportObject(params, types)
+	if err != nil {
+		return err
 	}
 
 	d, err := yaml.Marshal(jsonObj.Data())
@@ -184,17 +306,111 @@ func exportAsTsv(params map[string]string, w io.Writer) error {
 	return nil
 }
 
-func exportAsEnvFile(params map[string]string, w io.Writer) error {
+func exportAsEnvFile(params map[string]string, mapper environ.NameMapper, w io.Writer) error {
 	// Env like:
 	// KEY=val
 	// OTHER=otherval
 	for _, k := range sortedKeys(params) {
-		key := strings.ToUpper(k)
-		key = strings.ReplaceAll(key, "/", "_")
-		key = strings.ReplaceAll(key, "-", "_")
-		key = strings.ReplaceAll(key, ".", "_")
+		key, err := mapper.EnvVarName(k)
+		if err != nil {
+			return err
+		}
 
-		_, err := w.Write([]byte(fmt.Sprintf(`%s="%s"`+"\n", key, doubleQuoteEscape(params[k]))))
+		_, err = w.Write([]byte(fmt.Sprintf(`%s="%s"`+"\n", key, doubleQuoteEscape(params[k]))))
+		if err != nil {
+			return fmt.Errorf("failed to write param %s: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+func exportAsShell(params map[string]string, mapper environ.NameMapper, w io.Writer) error {
+	// POSIX shell like:
+	// export KEY='val'
+	for _, k := range sortedKeys(params) {
+		key, err := shellVariableName(mapper, k)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "export %s=%s\n", key, shellQuote(params[k]))
+		if err != nil {
+			return fmt.Errorf("failed to write param %s: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+func exportAsFish(params map[string]string, mapper environ.NameMapper, w io.Writer) error {
+	// fish shell like:
+	// set -gx KEY 'val'
+	for _, k := range sortedKeys(params) {
+		key, err := shellVariableName(mapper, k)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "set -gx %s %s;\n", key, fishQuote(params[k]))
+		if err != nil {
+			return fmt.Errorf("failed to write param %s: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+func exportAsPowerShell(params map[string]string, mapper environ.NameMapper, w io.Writer) error {
+	// PowerShell like:
+	// $env:KEY = 'val'
+	for _, k := range sortedKeys(params) {
+		key, err := shellVariableName(mapper, k)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "$env:%s = %s\n", key, powerShellQuote(params[k]))
+		if err != nil {
+			return fmt.Errorf("failed to write param %s: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+// shellVariableName maps the configuration key to the environment variable
+// name, which is written unquoted in shell code meant to be evaluated, so all
+// names are checked.
+func shellVariableName(mapper environ.NameMapper, k string) (string, error) {
+	key, err := mapper.EnvVarName(k)
+	if err != nil {
+		return "", err
+	}
+
+	if !environ.ValidName(key) {
+		return "", environ.InvalidNameError{Key: k, Name: key}
+	}
+
+	return key, nil
+}
+
+func exportAsDockerEnv(params map[string]string, mapper environ.NameMapper, w io.Writer) error {
+	// Docker env file like:
+	// KEY=val
+	// Docker takes everything after `=` literally, there is no quoting, so
+	// multiline values can not be represented.
+	for _, k := range sortedKeys(params) {
+		if strings.ContainsAny(params[k], "\r\n") {
+			return fmt.Errorf("param %s has multiline value, which is not supported by docker env file", k)
+		}
+
+		key, err := mapper.EnvVarName(k)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "%s=%s\n", key, params[k])
 		if err != nil {
 			return fmt.Errorf("failed to write param %s: %w", k, err)
 		}
@@ -217,15 +433,30 @@ func exportAsTfvars(params map[string]string, w io.Writer) error {
 	return nil
 }
 
-func exportAsTfEnvVars(params map[string]string, w io.Writer) error {
-	// Terraform Variables is like dotenv, but keeps the TF_VAR and keeps case
+// tfEnvVarPrefix is the prefix of env vars which set Terraform variables
+const tfEnvVarPrefix = "TF_VAR_"
+
+func exportAsTfEnvVars(params map[string]string, mapper environ.NameMapper, w io.Writer) error {
+	// Terraform Variables is like dotenv, but keeps the TF_VAR and keeps case.
+	// TF_VAR_ is part of the mapped name, so that the final name is checked.
+	tfMapper := environ.NameMapper{
+		Mappings: make(map[string]string, len(mapper.Mappings)),
+		Prefix:   tfEnvVarPrefix + mapper.Prefix,
+		KeepCase: true,
+		Template: mapper.Template,
+	}
+
+	for k, v := range mapper.Mappings {
+		tfMapper.Mappings[k] = tfEnvVarPrefix + v
+	}
+
 	for _, k := range sortedKeys(params) {
-		key := "TF_VAR_" + k
-		key = strings.ReplaceAll(key, "/", "_")
-		key = strings.ReplaceAll(key, "-", "_")
-		key = strings.ReplaceAll(key, ".", "_")
+		key, err := tfMapper.EnvVarName(k)
+		if err != nil {
+			return err
+		}
 
-		_, err := w.Write([]byte(fmt.Sprintf(`%s="%s"`+"\n", key, doubleQuoteEscape(params[k]))))
+		_, err = w.Write([]byte(fmt.Sprintf(`%s="%s"`+"\n", key, doubleQuoteEscape(params[k]))))
 		if err != nil {
 			return fmt.Errorf("failed to write param %s: %w", k, err)
 		}
@@ -233,3 +464,125 @@ func exportAsTfEnvVars(params map[string]string, w io.Writer) error {
 
 	return nil
 }
+
+// k8sManifestOptions holds settings of exported Kubernetes resource.
+type k8sManifestOptions struct {
+	// Kind is either `secret` or `configmap`
+	Kind       string
+	Name       string
+	Namespace  string
+	Labels     map[string]string
+	StringData bool
+}
+
+type k8sManifest struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   k8sObjectMeta     `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string]string `json:"data,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+type k8sObjectMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+var (
+	// k8sInvalidKeyChars matches characters not allowed in keys of secrets
+	// and config maps
+	k8sInvalidKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+	// k8sInvalidNameChars matches characters not allowed in resource names
+	k8sInvalidNameChars = regexp.MustCompile(`[^-.a-z0-9]+`)
+)
+
+func exportAsK8sManifest(params map[string]string, options k8sManifestOptions, w io.Writer) error {
+	// Kubernetes Secret or ConfigMap like:
+	// apiVersion: v1
+	// kind: Secret
+	// data:
+	//   param1: dmFsdWUx
+	manifest := k8sManifest{
+		APIVersion: "v1",
+		Metadata: k8sObjectMeta{
+			Name:      options.Name,
+			Namespace: options.Namespace,
+			Labels:    options.Labels,
+		},
+	}
+
+	if manifest.Metadata.Name == "" {
+		return errors.New("name of the resource must be specified")
+	}
+
+	data := map[string]string{}
+	sources := map[string]string{}
+
+	for _, k := range sortedKeys(params) {
+		key := k8sKey(k)
+
+		if other, ok := sources[key]; ok {
+			return fmt.Errorf("parameters %s and %s have the same key %s", other, k, key)
+		}
+
+		sources[key] = k
+		data[key] = params[k]
+	}
+
+	switch options.Kind {
+	case "secret":
+		manifest.Kind = "Secret"
+		manifest.Type = "Opaque"
+
+		if options.StringData {
+			manifest.StringData = data
+			break
+		}
+
+		for k, v := range data {
+			data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+
+		manifest.Data = data
+	case "configmap":
+		manifest.Kind = "ConfigMap"
+		manifest.Data = data
+	default:
+		return fmt.Errorf("unsupported kind: %s", options.Kind)
+	}
+
+	d, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest to YAML: %w", err)
+	}
+
+	_, err = w.Write(d)
+	if err != nil {
+		return fmt.Errorf("failed to write bytes to Writer: %w", err)
+	}
+
+	return nil
+}
+
+// k8sKey converts parameter name to a valid key of secret or config map,
+// replacing path separators and other invalid characters with underscores.
+func k8sKey(k string) string {
+	return k8sInvalidKeyChars.ReplaceAllString(k, "_")
+}
+
+// k8sResourceName returns the name if given, otherwise it derives a valid
+// resource name (DNS subdomain) from the prefix.
+func k8sResourceName(name, prefix string) string {
+	if name != "" {
+		return name
+	}
+
+	name = strings.ToLower(strings.Trim(prefix, pathSeparator))
+	name = strings.ReplaceAll(name, pathSeparator, "-")
+	name = k8sInvalidNameChars.ReplaceAllString(name, "-")
+
+	return strings.Trim(name, "-.")
+}
@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"bytes"
+	"io"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/sicc/pkg/environ"
 )
 
 func TestExportDotenv(t *testing.T) {
@@ -40,10 +43,179 @@ func TestExportDotenv(t *testing.T) {
 		test := test
 		t.Run(test.name, func(t *testing.T) {
 			buf := &bytes.Buffer{}
-			err := exportAsEnvFile(test.params, buf)
+			err := exportAsEnvFile(test.params, environ.NameMapper{}, buf)
 
 			assert.Nil(t, err)
 			assert.ElementsMatch(t, test.output, strings.Split(strings.Trim(buf.String(), "\n"), "\n"))
 		})
 	}
 }
+
+func TestExportK8sManifest(t *testing.T) {
+	params := map[string]string{"db/password": "pass", "tls/cert.pem": "line1\nline2"}
+
+	buf := &bytes.Buffer{}
+	err := exportAsK8sManifest(params, k8sManifestOptions{
+		Kind:      "secret",
+		Name:      "api",
+		Namespace: "prod",
+		Labels:    map[string]string{"app": "api"},
+	}, buf)
+	assert.Nil(t, err)
+	assert.Equal(t, `apiVersion: v1
+data:
+  db_password: cGFzcw==
+  tls_cert.pem: bGluZTEKbGluZTI=
+kind: Secret
+metadata:
+  labels:
+    app: api
+  name: api
+  namespace: prod
+type: Opaque
+`, buf.String())
+
+	buf.Reset()
+	err = exportAsK8sManifest(params, k8sManifestOptions{Kind: "secret", Name: "api", StringData: true}, buf)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "stringData:\n  db_password: pass\n")
+
+	buf.Reset()
+	err = exportAsK8sManifest(params, k8sManifestOptions{Kind: "configmap", Name: "api"}, buf)
+	assert.Nil(t, err)
+	assert.Contains(t, buf.String(), "kind: ConfigMap\n")
+	assert.Contains(t, buf.String(), "  tls_cert.pem: |-\n    line1\n    line2\n")
+
+	err = exportAsK8sManifest(map[string]string{"a/b": "1", "a_b": "2"}, k8sManifestOptions{Kind: "configmap", Name: "api"}, buf)
+	assert.Error(t, err)
+
+	assert.Equal(t, "dev-my-app", k8sResourceName("", "/dev/My_App/"))
+	assert.Equal(t, "custom", k8sResourceName("custom", "/dev/app"))
+}
+
+func TestExportShells(t *testing.T) {
+	params := map[string]string{"db/password": `it's "$x" \n`, "multi": "a\nb"}
+
+	tests := []struct {
+		name   string
+		export func(map[string]string, environ.NameMapper, io.Writer) error
+		output string
+	}{
+		{
+			"shell",
+			exportAsShell,
+			"export DB_PASSWORD='it'\\''s \"$x\" \\n'\nexport MULTI='a\nb'\n",
+		},
+		{
+			"fish",
+			exportAsFish,
+			"set -gx DB_PASSWORD 'it\\'s \"$x\" \\\\n';\nset -gx MULTI 'a\nb';\n",
+		},
+		{
+			"powershell",
+			exportAsPowerShell,
+			"$env:DB_PASSWORD = 'it''s \"$x\" \\n'\n$env:MULTI = 'a\nb'\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			err := test.export(params, environ.NameMapper{}, buf)
+			assert.Nil(t, err)
+			assert.Equal(t, test.output, buf.String())
+
+			for _, key := range []string{"x=$(touch pwned);y", "db pass", "1db/pass", "a@b+c"} {
+				buf.Reset()
+
+				err = test.export(map[string]string{key: "val"}, environ.NameMapper{}, buf)
+				assert.Error(t, err)
+				assert.Empty(t, buf.String())
+			}
+		})
+	}
+
+	t.Run("docker-env", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		err := exportAsDockerEnv(map[string]string{"db/password": `"quoted" $x`}, environ.NameMapper{}, buf)
+		assert.Nil(t, err)
+		assert.Equal(t, "DB_PASSWORD=\"quoted\" $x\n", buf.String())
+
+		err = exportAsDockerEnv(params, environ.NameMapper{}, buf)
+		assert.Error(t, err)
+	})
+}
+
+func TestExportEnvNames(t *testing.T) {
+	params := map[string]string{"db/password": "pass", "db/user-name": "admin"}
+
+	tmpl, err := environ.NewNameTemplate(`{{ .Key | replace "/" "__" | replace "-" "_" | upper }}`)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name   string
+		export func(map[string]string, environ.NameMapper, io.Writer) error
+		mapper environ.NameMapper
+		output string
+	}{
+		{
+			"dotenv",
+			exportAsEnvFile,
+			environ.NameMapper{Mappings: map[string]string{"db/password": "DATABASE_PASSWORD"}, Prefix: "APP_"},
+			"DATABASE_PASSWORD=\"pass\"\nAPP_DB_USER_NAME=\"admin\"\n",
+		},
+		{
+			"dotenv keep case",
+			exportAsEnvFile,
+			environ.NameMapper{KeepCase: true},
+			"db_password=\"pass\"\ndb_user_name=\"admin\"\n",
+		},
+		{
+			"dotenv template",
+			exportAsEnvFile,
+			environ.NameMapper{Template: tmpl},
+			"DB__PASSWORD=\"pass\"\nDB__USER_NAME=\"admin\"\n",
+		},
+		{
+			"tfenvvars",
+			exportAsTfEnvVars,
+			environ.NameMapper{Mappings: map[string]string{"db/password": "db_pass"}},
+			"TF_VAR_db_pass=\"pass\"\nTF_VAR_db_user_name=\"admin\"\n",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			err := test.export(params, test.mapper, buf)
+			assert.Nil(t, err)
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+
+	t.Run("leading digit", func(t *testing.T) {
+		params := map[string]string{"1password": "pass"}
+		buf := &bytes.Buffer{}
+
+		err := exportAsTfEnvVars(params, environ.NameMapper{}, buf)
+		assert.Nil(t, err)
+		assert.Equal(t, "TF_VAR_1password=\"pass\"\n", buf.String())
+
+		buf.Reset()
+
+		err = exportAsEnvFile(params, environ.NameMapper{}, buf)
+		assert.Nil(t, err)
+		assert.Equal(t, "1PASSWORD=\"pass\"\n", buf.String())
+
+		err = exportAsEnvFile(params, environ.NameMapper{Prefix: "1"}, buf)
+		assert.Equal(t, environ.InvalidNameError{Key: "1password", Name: "11PASSWORD"}, err)
+
+		err = exportAsTfEnvVars(map[string]string{"db/pass word": "pass"}, environ.NameMapper{}, buf)
+		assert.Error(t, err)
+	})
+}
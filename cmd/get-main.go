@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -21,13 +23,17 @@ var getCmd = &cobra.Command{
 
 var getParameters struct {
 	Version int
+	Stage   string
 	Quiet   bool
+	Expand  bool
 }
 
 //nolint:lll
 func init() {
 	getCmd.Flags().IntVarP(&getParameters.Version, "version", "v", -1, "The version number of the secret. Defaults to latest.")
+	getCmd.Flags().StringVar(&getParameters.Stage, "stage", "", "The version stage of the secret, like AWSPREVIOUS. Only supported by secretsmanager backend.")
 	getCmd.Flags().BoolVarP(&getParameters.Quiet, "quiet", "q", false, "Only print the secret")
+	getCmd.Flags().BoolVar(&getParameters.Expand, "expand", false, "Expand references to other configurations, like ${/prod/db/host}")
 	// add 'get' command to root command
 	rootCmd.AddCommand(getCmd)
 }
@@ -39,11 +45,22 @@ func runGet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
+	if getParameters.Stage != "" && cmd.Flags().Changed("version") {
+		return errors.New("--stage can not be used together with --version")
+	}
+
 	configStore, err := getConfigurationStore()
 	if err != nil {
 		return fmt.Errorf("failed to get configuration store: %w", err)
 	}
 
+	if getParameters.Expand {
+		configStore = store.NewExpandingStore(configStore)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	path, name := path.Split(configPathName)
 
 	parameterName := store.ParameterName{
@@ -51,7 +68,7 @@ func runGet(cmd *cobra.Command, args []string) error {
 		Name:          name,
 	}
 
-	config, err := configStore.Get(parameterName, getParameters.Version)
+	config, err := getConfig(ctx, configStore, parameterName)
 	if err != nil {
 		return fmt.Errorf("failed to fetch configuration: %w", err)
 	}
@@ -63,17 +80,33 @@ func runGet(cmd *cobra.Command, args []string) error {
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
 
-	fmt.Fprintln(w, "Key\tValue\tVersion\tSecure\tLastModified\tUser")
-	fmt.Fprintf(w, "%s\t%s\t%d\t%t\t%s\t%s\n",
+	fmt.Fprintln(w, "Key\tValue\tVersion\tSecure\tKMSKey\tLastModified\tUser\tDescription")
+	fmt.Fprintf(w, "%s\t%s\t%d\t%t\t%s\t%s\t%s\t%s\n",
 		config.Meta.Key,
 		*config.Value,
 		config.Meta.Version,
 		config.Meta.Secure,
+		config.Meta.KeyID,
 		config.Meta.LastModifiedDate.Local().Format(shortTimeFormat),
 		config.Meta.LastModifiedUser,
+		config.Meta.Description,
 	)
 
 	w.Flush()
 
 	return nil
 }
+
+// getConfig reads the configuration at the version or stage given by flags.
+func getConfig(ctx context.Context, configStore store.Store, name store.ParameterName) (store.Value, error) {
+	if getParameters.Stage == "" {
+		return configStore.Get(ctx, name, getParameters.Version)
+	}
+
+	stageStore, ok := configStore.(store.StageStore)
+	if !ok {
+		return store.Value{}, store.ErrStagesNotSupported
+	}
+
+	return stageStore.GetStage(ctx, name, getParameters.Stage)
+}
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"strconv"
+	"time"
+
+	"github.com/zbiljic/sicc/store"
 )
 
 const (
@@ -24,6 +28,16 @@ var (
 	globalVerbose    = false             // Verbose flag set via command line
 	globalBackend    = "ssm"             // Backend flag set via command line
 	globalNumRetries = defaultNumRetries // Retries flag set via command line
+	globalTimeout    = time.Duration(0)  // Timeout flag set via command line
+	globalFile       = ""                // File flag set via command line
+	globalKeyFile    = ""                // Key file flag set via command line
+	globalProfile    = ""                // Profile flag set via command line
+
+	globalSSMLegacyVersions = false               // SSM legacy versions flag set via command line
+	globalKMSKey            = ""                  // KMS key flag set via command line
+	globalKMSKeyMap         = map[string]string{} // KMS key map flag set via command line
+
+	globalRecoveryWindow = store.DefaultRecoveryWindowInDays // Recovery window flag set via command line
 	// WHEN YOU ADD NEXT GLOBAL FLAG, MAKE SURE TO ALSO UPDATE PERSISTENT FLAGS, FLAG CONSTANTS AND UPDATE FUNC.
 )
 
@@ -31,9 +45,22 @@ const (
 	verboseEnvVar = "SICC_VERBOSE"
 	backendEnvVar = "SICC_BACKEND"
 	retriesEnvVar = "SICC_RETRIES"
+	timeoutEnvVar = "SICC_TIMEOUT"
+	fileEnvVar    = "SICC_FILE"
+	keyFileEnvVar = "SICC_KEY_FILE"
+	profileEnvVar = "SICC_PROFILE"
+
+	recoveryWindowEnvVar    = "SICC_RECOVERY_WINDOW"
+	ssmLegacyVersionsEnvVar = "SICC_SSM_LEGACY_VERSIONS"
+	kmsKeyEnvVar            = "SICC_KMS_KEY"
+	kmsKeyMapEnvVar         = "SICC_KMS_KEY_MAP"
+
+	// passphraseEnvVar is only read from the environment, passphrase is not
+	// accepted as a flag to keep it out of shell history and process list.
+	passphraseEnvVar = "SICC_PASSPHRASE"
 )
 
-func updateGlobals() {
+func updateGlobals() error {
 	if verbose, ok := os.LookupEnv(verboseEnvVar); ok {
 		globalVerbose, _ = strconv.ParseBool(verbose)
 	}
@@ -45,4 +72,43 @@ func updateGlobals() {
 	if retries, ok := os.LookupEnv(retriesEnvVar); ok {
 		globalNumRetries, _ = strconv.Atoi(retries)
 	}
+
+	if timeout, ok := os.LookupEnv(timeoutEnvVar); ok {
+		globalTimeout, _ = time.ParseDuration(timeout)
+	}
+
+	if file, ok := os.LookupEnv(fileEnvVar); ok {
+		globalFile = file
+	}
+
+	if keyFile, ok := os.LookupEnv(keyFileEnvVar); ok {
+		globalKeyFile = keyFile
+	}
+
+	if profile, ok := os.LookupEnv(profileEnvVar); ok {
+		globalProfile = profile
+	}
+
+	if recoveryWindow, ok := os.LookupEnv(recoveryWindowEnvVar); ok {
+		var err error
+
+		globalRecoveryWindow, err = strconv.Atoi(recoveryWindow)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", recoveryWindowEnvVar, err)
+		}
+	}
+
+	if ssmLegacyVersions, ok := os.LookupEnv(ssmLegacyVersionsEnvVar); ok {
+		globalSSMLegacyVersions, _ = strconv.ParseBool(ssmLegacyVersions)
+	}
+
+	if kmsKey, ok := os.LookupEnv(kmsKeyEnvVar); ok {
+		globalKMSKey = kmsKey
+	}
+
+	if kmsKeyMap, ok := os.LookupEnv(kmsKeyMapEnvVar); ok {
+		globalKMSKeyMap = parseKeyValuePairs(kmsKeyMap)
+	}
+
+	return nil
 }
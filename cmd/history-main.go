@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+// historyCmd represents the 'history' command
+var historyCmd = &cobra.Command{
+	Use:   "history <path>",
+	Short: "Show all versions of the configuration",
+	Args:  cobra.ExactArgs(1), //nolint:gomnd
+	RunE:  runHistory,
+}
+
+// unavailableValue is shown in place of values which are not available
+const unavailableValue = "<unavailable>"
+
+var historyParameters struct {
+	WithValues bool
+	Format     string
+}
+
+type historyEntry struct {
+	Version      int       `json:"version"`
+	LastModified time.Time `json:"lastModified"`
+	User         string    `json:"user,omitempty"`
+	Secure       bool      `json:"secure"`
+	KMSKey       string    `json:"kmsKey,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Value        *string   `json:"value,omitempty"`
+}
+
+func init() {
+	historyCmd.Flags().BoolVar(&historyParameters.WithValues, "values", false, "Include values of all versions")
+	historyCmd.Flags().StringVarP(&historyParameters.Format, "format", "f", "table", "Output format (table, json)")
+	// add 'history' command to root command
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	configPathName := path.Join(pathSeparator, args[0])
+
+	if err := validateConfigPathName(configPathName); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	configStore, err := getConfigurationStore()
+	if err != nil {
+		return fmt.Errorf("failed to get configuration store: %w", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	path, name := path.Split(configPathName)
+
+	parameterName := store.ParameterName{
+		ParameterPath: path,
+		Name:          name,
+	}
+
+	history, err := configStore.History(ctx, parameterName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch configuration history: %w", err)
+	}
+
+	// newest version first
+	entries := make([]historyEntry, 0, len(history))
+
+	for i := len(history) - 1; i >= 0; i-- {
+		config := history[i]
+
+		entry := historyEntry{
+			Version:      config.Meta.Version,
+			LastModified: config.Meta.LastModifiedDate,
+			User:         config.Meta.LastModifiedUser,
+			Secure:       config.Meta.Secure,
+			KMSKey:       config.Meta.KeyID,
+			Description:  config.Meta.Description,
+		}
+
+		if historyParameters.WithValues {
+			entry.Value = config.Value
+		}
+
+		entries = append(entries, entry)
+	}
+
+	switch strings.ToLower(historyParameters.Format) {
+	case "table":
+		printHistoryTable(entries)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(entries); err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported history format: %s", historyParameters.Format)
+	}
+
+	return nil
+}
+
+func printHistoryTable(entries []historyEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
+
+	fmt.Fprint(w, "Version\tLastModified\tUser\tSecure\tKMSKey\tDescription")
+
+	if historyParameters.WithValues {
+		fmt.Fprint(w, "\tValue")
+	}
+
+	fmt.Fprintln(w, "")
+
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\t%s",
+			entry.Version,
+			entry.LastModified.Local().Format(shortTimeFormat),
+			entry.User,
+			entry.Secure,
+			entry.KMSKey,
+			entry.Description,
+		)
+
+		if historyParameters.WithValues {
+			fmt.Fprintf(w, "\t%s", historyValue(entry.Value))
+		}
+
+		fmt.Fprintln(w, "")
+	}
+
+	w.Flush()
+}
+
+// historyValue returns the value of the version, or a placeholder when the
+// backend does not return it (e.g. destroyed versions).
+func historyValue(value *string) string {
+	if value == nil {
+		return unavailableValue
+	}
+
+	return *value
+}
@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryValue(t *testing.T) {
+	value := "pass"
+
+	assert.Equal(t, "pass", historyValue(&value))
+	assert.Equal(t, "<unavailable>", historyValue(nil))
+}
@@ -1,14 +1,27 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/ghodss/yaml"
 	"github.com/jeremywohl/flatten"
 	"github.com/spf13/cast"
 	"github.com/spf13/cobra"
+	yamlv2 "gopkg.in/yaml.v2"
 
 	utilyaml "github.com/zbiljic/sicc/pkg/util/yaml"
 	"github.com/zbiljic/sicc/store"
@@ -18,16 +31,81 @@ import (
 var importCmd = &cobra.Command{
 	Use:   "import <path> <file|->",
 	Short: "Import configurations from file",
-	Args:  cobra.ExactArgs(2), //nolint:gomnd
-	RunE:  runImport,
+	Long: `Import configurations from file.
+
+The file format is detected from the file extension, unless set with --format.
+Keys of dotenv and tfenvvars files are lowercased, and TF_VAR_ prefix is
+removed from tfenvvars keys. Other characters are kept, so keys exported with
+'/' separators or mixed case are not restored: the round trip through these
+formats is lossy, and /db/password is imported as db_password. Standard input
+is read as JSON or YAML by default.
+
+JSON and YAML input may contain multiple documents. With --document-path-key,
+the top-level key of that name sets the path where the document is imported,
+and is not imported itself. Relative paths are joined to the import path;
+paths outside the import path are rejected, unless --allow-outside-path is set.
+
+With --secret all configurations are stored as secrets. Only some of them can be
+marked as secrets with --secret-keys glob patterns, which are matched against
+the key relative to the document path, and against its last element (absolute
+patterns are matched against the full path), or with --secret-keys-regex
+regular expressions matched against the relative key.
+
+With --typed the types of JSON and YAML values are preserved: arrays are kept
+as a single configuration stored as JSON, and the type of numbers, booleans and
+arrays is recorded at the end of the description, so that 'export --typed'
+produces the original values. Values themselves are stored as they are,
+including the literal text of numbers. Descriptions are otherwise kept, and the
+recorded type is removed when the configuration is written without --typed.
+Backends which do not keep descriptions (secretsmanager, and ssm with
+--ssm-legacy-versions) do not support --typed.
+
+Configurations are written by --concurrency workers; all workers slow down
+while the backend is throttling requests. Import stops at the first failure,
+unless --continue-on-error is set, and prints the failed configurations.
+
+With --plan nothing is written; configurations which would be created, updated
+or removed are printed, and the command fails if there are any changes.`,
+	Args: cobra.ExactArgs(2), //nolint:gomnd
+	RunE: runImport,
 }
 
 var importParameters struct {
-	Secret bool
+	Secret           bool
+	SecretKeys       []string
+	SecretKeysRegexp []string
+	Format           string
+	Typed            bool
+	Plan             bool
+	Prune            bool
+	ShowValues       bool
+	Concurrency      int
+	ContinueOnError  bool
+	DocumentPathKey  string
+	AllowOutsidePath bool
 }
 
+// errImportPlanChanges is returned when import plan is not empty.
+var errImportPlanChanges = errors.New("import plan has changes")
+
+// errTypedWithoutDescriptions is returned in typed mode when the store does
+// not keep descriptions, where types of values are recorded.
+var errTypedWithoutDescriptions = errors.New("--typed is not supported by backends which do not keep descriptions")
+
+//nolint:lll
 func init() {
 	importCmd.Flags().BoolVar(&importParameters.Secret, "secret", false, "Add configurations as secrets")
+	importCmd.Flags().StringSliceVar(&importParameters.SecretKeys, "secret-keys", []string{}, "Add configurations matching glob patterns as secrets, e.g. '*password*,db/*'")
+	importCmd.Flags().StringArrayVar(&importParameters.SecretKeysRegexp, "secret-keys-regex", []string{}, "Add configurations matching regular expression as secrets (can be repeated)")
+	importCmd.Flags().StringVarP(&importParameters.Format, "format", "f", "", "Input format (json, yaml, csv, tsv, dotenv, tfvars, tfenvvars, properties), detected from file extension by default")
+	importCmd.Flags().BoolVar(&importParameters.Typed, "typed", false, "Preserve types of values (numbers, booleans and arrays)")
+	importCmd.Flags().BoolVar(&importParameters.Plan, "plan", false, "Only show what would change, and exit with error if there are changes")
+	importCmd.Flags().BoolVar(&importParameters.Prune, "prune", false, "Delete configurations under the path which are missing from the file")
+	importCmd.Flags().BoolVar(&importParameters.ShowValues, "show-values", false, "Show values of the configurations in the plan (masked by default)")
+	importCmd.Flags().IntVar(&importParameters.Concurrency, "concurrency", 1, "Number of configurations written in parallel")
+	importCmd.Flags().StringVar(&importParameters.DocumentPathKey, "document-path-key", "", "Top-level key of JSON and YAML documents holding the path where the document is imported, e.g. __sicc_path")
+	importCmd.Flags().BoolVar(&importParameters.AllowOutsidePath, "allow-outside-path", false, "Allow document paths outside the import path")
+	importCmd.Flags().BoolVar(&importParameters.ContinueOnError, "continue-on-error", false, "Continue importing remaining configurations after a failure")
 	// add 'import' command to root command
 	rootCmd.AddCommand(importCmd)
 }
@@ -40,70 +118,844 @@ func runImport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
+	secretKeys, err := newSecretKeyMatcher(importParameters.SecretKeys, importParameters.SecretKeysRegexp)
+	if err != nil {
+		return err
+	}
+
+	documents, err := readImportFile(args[1], importParameters.Format, importParameters.Typed, importParameters.DocumentPathKey)
+	if err != nil {
+		return err
+	}
+
+	desired := map[string]store.Value{}
+	// types holds types of values in typed mode
+	types := map[string]string{}
+	// seen holds keys in the input, including those with empty values which
+	// are not written, so they are not pruned
+	seen := map[string]bool{}
+	targets := []string{}
+	total := 0
+
+	for _, document := range documents {
+		target, err := document.target(configPathName, importParameters.AllowOutsidePath)
+		if err != nil {
+			return err
+		}
+
+		targets = append(targets, target)
+
+		for key, value := range document.Params {
+			configPath := path.Join(target, key)
+
+			v := cast.ToString(value)
+
+			var valueType string
+
+			if importParameters.Typed {
+				if v, valueType, err = encodeTypedValue(value); err != nil {
+					return fmt.Errorf("%s: failed to encode value of `%s`: %w", document, configPath, err)
+				}
+			}
+
+			total++
+
+			seen[configPath] = true
+
+			if v == "" {
+				continue
+			}
+
+			if _, ok := desired[configPath]; ok {
+				fmt.Fprintf(os.Stderr, "warning: parameter %s specified more than once (overridden by %s)\n", configPath, document)
+			}
+
+			desired[configPath] = store.Value{
+				Value: &v,
+				Meta: store.Metadata{
+					Secure: importParameters.Secret || secretKeys.match(key, configPath),
+				},
+			}
+			types[configPath] = valueType
+		}
+	}
+
+	configStore, err := getConfigurationStore()
+	if err != nil {
+		return fmt.Errorf("failed to get configuration store: %w", err)
+	}
+
+	if importParameters.Typed && !store.KeepsDescription(configStore) {
+		return errTypedWithoutDescriptions
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	current := map[string]store.Value{}
+
+	for _, target := range targets {
+		configs, err := configStore.List(ctx, target, true)
+		if err != nil {
+			return fmt.Errorf("failed to list store contents (%s): %w", target, err)
+		}
+
+		for _, config := range configs {
+			current[config.Meta.Key] = config
+		}
+	}
+
+	setTypeDescriptions(desired, current, types)
+
+	for k, value := range desired {
+		value.Meta.KeyID = kmsKeyFor(configStore, parameterNameFromPath(k), value.Meta.Secure)
+		desired[k] = value
+	}
+
+	plan := syncPlan(current, desired, importParameters.Prune, seen, planMetadataOf(configStore))
+
+	if importParameters.Plan {
+		fmt.Printf("Plan: %d to create, %d to update, %d unchanged, %d to remove\n",
+			len(plan.Added), len(plan.Changed), len(plan.Unchanged), len(plan.Removed))
+		printConfigDiff(os.Stdout, plan, rawValuesOf(current), rawValuesOf(desired), importParameters.ShowValues)
+
+		if !plan.empty() {
+			return errImportPlanChanges
+		}
+
+		return nil
+	}
+
+	toBeWritten := append(append([]string{}, plan.Added...), plan.Changed...)
+
+	failures := parallelApply(ctx, toBeWritten, importParameters.Concurrency, importParameters.ContinueOnError, "Importing",
+		func(ctx context.Context, configPath string) error {
+			return configStore.Put(ctx, parameterNameFromPath(configPath), desired[configPath])
+		})
+
+	importedCount := len(toBeWritten) - len(failures)
+
+	// removing is skipped after failures, so the data is not lost
+	if len(failures) == 0 {
+		failures = parallelApply(ctx, plan.Removed, importParameters.Concurrency, importParameters.ContinueOnError, "Removing",
+			func(ctx context.Context, configPath string) error {
+				return deleteFromStore(ctx, configStore, configPath)
+			})
+	}
+
+	if len(failures) > 0 {
+		printFailures(failures)
+		return fmt.Errorf("failed to import %d configurations", len(failures))
+	}
+
+	fmt.Fprintf(os.Stdout, "Successfully imported %d/%d configurations\n", importedCount, total)
+
+	return nil
+}
+
+// setTypeDescriptions sets descriptions of desired configurations to the
+// current ones, with the recorded type replaced by the type of the value, or
+// removed for untyped values.
+func setTypeDescriptions(desired, current map[string]store.Value, types map[string]string) {
+	for k, value := range desired {
+		value.Meta.Description = withTypeDescription(current[k].Meta.Description, types[k])
+		desired[k] = value
+	}
+}
+
+// secretKeyMatcher selects imported configurations stored as secrets.
+type secretKeyMatcher struct {
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+func newSecretKeyMatcher(globs, regexps []string) (*secretKeyMatcher, error) {
+	m := &secretKeyMatcher{}
+
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid secret keys pattern `%s`: %w", glob, err)
+		}
+
+		m.globs = append(m.globs, glob)
+	}
+
+	for _, expr := range regexps {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret keys regular expression `%s`: %w", expr, err)
+		}
+
+		m.regexps = append(m.regexps, re)
+	}
+
+	return m, nil
+}
+
+// match reports whether the configuration is a secret. Key is relative to
+// the document path, while configPath is the full path.
+func (m *secretKeyMatcher) match(key, configPath string) bool {
+	for _, glob := range m.globs {
+		if path.IsAbs(glob) {
+			if ok, _ := path.Match(glob, configPath); ok {
+				return true
+			}
+
+			continue
+		}
+
+		if ok, _ := path.Match(glob, key); ok {
+			return true
+		}
+
+		if ok, _ := path.Match(glob, path.Base(key)); ok {
+			return true
+		}
+	}
+
+	for _, re := range m.regexps {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importDocument holds configurations of a single input document, keyed by
+// path relative to the target path.
+type importDocument struct {
+	Index  int
+	Line   int
+	Path   string
+	Params map[string]interface{}
+}
+
+func (d importDocument) String() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("document %d", d.Index)
+	}
+
+	return fmt.Sprintf("document %d (line %d)", d.Index, d.Line)
+}
+
+// target returns the path where configurations of the document are imported.
+// Relative document path is joined to the import path. Document path must be
+// under the import path, unless outside paths are allowed.
+func (d importDocument) target(configPathName string, allowOutside bool) (string, error) {
+	target := configPathName
+
+	switch {
+	case d.Path == "":
+	case path.IsAbs(d.Path):
+		target = path.Clean(d.Path)
+	default:
+		target = path.Join(configPathName, d.Path)
+	}
+
+	if err := validateConfigPathName(target); err != nil {
+		return "", fmt.Errorf("%s: validation failed: %w", d, err)
+	}
+
+	if !allowOutside && !store.HasPathPrefix(target, configPathName) {
+		return "", fmt.Errorf("%s: path `%s` is outside of the import path `%s` (use --allow-outside-path)", d, target, configPathName)
+	}
+
+	return target, nil
+}
+
+// readImportFile reads file, or standard input for `-`, in the given format
+// and returns its documents. Only JSON and YAML inputs may contain multiple
+// documents. Format is detected from file extension when empty. In typed mode
+// arrays are not flattened.
+func readImportFile(file, format string, typed bool, pathKey string) ([]importDocument, error) {
 	var in io.Reader
 
-	file := args[1]
 	if file == "-" {
 		in = os.Stdin
 	} else {
-		var err error
-		in, err = os.Open(file)
+		f, err := os.Open(file)
 		if err != nil {
-			return fmt.Errorf("failed to open file: %w", err)
+			return nil, fmt.Errorf("failed to open file: %w", err)
 		}
+		defer f.Close()
+
+		in = f
+	}
+
+	if format == "" {
+		format = importFormatFromFileName(file)
 	}
 
-	var data map[string]interface{}
+	var (
+		params map[string]interface{}
+		err    error
+	)
 
-	if err := utilyaml.NewYAMLOrJSONDecoder(in, 16).Decode(&data); err != nil {
-		return fmt.Errorf("failed to decode input as JSON or YAML: %w", err)
+	switch strings.ToLower(format) {
+	case "", "json", "yaml":
+		return importFromYAMLOrJSON(in, typed, pathKey)
+	case "csv":
+		params, err = importFromCsv(in)
+	case "tsv":
+		params, err = importFromTsv(in)
+	case "dotenv":
+		params, err = importFromEnvFile(in, "")
+	case "tfvars":
+		params, err = importFromTfvars(in)
+	case "tfenvvars":
+		params, err = importFromEnvFile(in, "TF_VAR_")
+	case "properties":
+		params, err = importFromProperties(in)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
 	}
 
-	toBeImported, err := flatten.Flatten(data, "", flatten.PathStyle)
 	if err != nil {
-		return fmt.Errorf("failed to flatten input: %w", err)
+		return nil, err
 	}
 
-	configStore, err := getConfigurationStore()
+	return []importDocument{{Index: 1, Line: 1, Params: params}}, nil
+}
+
+// importFormatFromFileName detects import format from file extension. Empty
+// format means JSON or YAML.
+func importFormatFromFileName(file string) string {
+	base := strings.ToLower(filepath.Base(file))
+
+	switch ext := filepath.Ext(base); {
+	case ext == ".csv":
+		return "csv"
+	case ext == ".tsv":
+		return "tsv"
+	case ext == ".env" || base == ".env" || strings.HasPrefix(base, ".env."):
+		return "dotenv"
+	case ext == ".tfvars":
+		return "tfvars"
+	case ext == ".properties":
+		return "properties"
+	default:
+		return ""
+	}
+}
+
+// yamlErrorLine matches line number in YAML errors.
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// importFromYAMLOrJSON decodes all documents of JSON or YAML stream. The
+// top-level pathKey of documents, when set, holds the document path.
+func importFromYAMLOrJSON(in io.Reader, typed bool, pathKey string) ([]importDocument, error) {
+	data, err := ioutil.ReadAll(in)
 	if err != nil {
-		return fmt.Errorf("failed to get configuration store: %w", err)
+		return nil, fmt.Errorf("failed to read input: %w", err)
 	}
 
-	importedCount := 0
+	documents := []importDocument{}
 
-	for key, value := range toBeImported {
-		parameterName := store.ParameterName{
-			ParameterPath: configPathName,
-			Name:          key,
-		}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		// JSON stream, line numbers are reported by the decoder
+		decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 16)
 
-		v := cast.ToString(value)
+		for index := 1; ; index++ {
+			document := importDocument{Index: index}
 
-		if v != "" {
-			val := store.Value{
-				Value: &v,
-				Meta: store.Metadata{
-					Secure: importParameters.Secret,
-				},
-			}
+			var raw json.RawMessage
 
-			// Skip writing configuration if value is unchanged
-			currentConfig, err := configStore.Get(parameterName, -1)
-			if err == nil && value == *currentConfig.Value && currentConfig.Meta.Secure == val.Meta.Secure {
-				continue
+			if err := decoder.Decode(&raw); err != nil {
+				if err == io.EOF {
+					break
+				}
+
+				return nil, fmt.Errorf("failed to decode %s as JSON: %w", document, err)
 			}
 
-			fmt.Printf("Importing `%s`\n", path.Join(configPathName, key))
+			obj, err := decodeJSONObject(raw, typed)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s as JSON: %w", document, err)
+			}
 
-			if err := configStore.Put(parameterName, val); err != nil {
-				return fmt.Errorf("failed to write configuration `%s`: %w", v, err)
+			if err := document.setParams(obj, typed, pathKey); err != nil {
+				return nil, err
 			}
 
-			importedCount++
+			documents = append(documents, document)
+		}
+
+		return documents, nil
+	}
+
+	for i, doc := range utilyaml.SplitDocuments(data) {
+		document := importDocument{Index: i + 1, Line: doc.Line}
+
+		obj, err := decodeYAMLObject(doc.Data, typed)
+		if err != nil {
+			// report lines relative to the whole stream
+			msg := yamlErrorLine.ReplaceAllStringFunc(err.Error(), func(s string) string {
+				n, _ := strconv.Atoi(yamlErrorLine.FindStringSubmatch(s)[1])
+				return fmt.Sprintf("line %d", doc.Line+n-1)
+			})
+
+			return nil, fmt.Errorf("failed to decode %s as YAML: %s", document, msg)
+		}
+
+		if obj == nil {
+			continue
+		}
+
+		if err := document.setParams(obj, typed, pathKey); err != nil {
+			return nil, err
 		}
+
+		documents = append(documents, document)
 	}
 
-	fmt.Fprintf(os.Stdout, "Successfully imported %d/%d configurations\n", importedCount, len(toBeImported))
+	return documents, nil
+}
+
+// decodeJSONObject decodes JSON object. In typed mode numbers are decoded as
+// json.Number, keeping their literal text.
+func decodeJSONObject(data []byte, typed bool) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	if typed {
+		decoder.UseNumber()
+	}
+
+	var obj map[string]interface{}
+
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// decodeYAMLObject decodes YAML mapping, the same way as JSON objects. In
+// typed mode numbers are decoded as json.Number, keeping their literal text.
+func decodeYAMLObject(data []byte, typed bool) (map[string]interface{}, error) {
+	if !typed {
+		var obj map[string]interface{}
+
+		err := yaml.Unmarshal(data, &obj)
+
+		return obj, err
+	}
+
+	var value typedYAMLValue
+
+	if err := yamlv2.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	if value.value == nil {
+		return nil, nil
+	}
+
+	obj, ok := value.value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("document is not a mapping")
+	}
+
+	return obj, nil
+}
+
+// jsonNumberFormat matches numbers written in JSON syntax.
+var jsonNumberFormat = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// typedYAMLValue holds YAML value decoded for typed mode. Numbers written in
+// JSON syntax are kept as json.Number with their literal text, others (like
+// 0x1f) are converted to it.
+type typedYAMLValue struct {
+	value interface{}
+}
+
+func (v *typedYAMLValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value interface{}
+
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+
+	switch value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]*typedYAMLValue{}
+
+		if err := unmarshal(&m); err != nil {
+			return err
+		}
+
+		obj := make(map[string]interface{}, len(m))
+
+		for k, e := range m {
+			obj[k] = e.get()
+		}
+
+		v.value = obj
+	case []interface{}:
+		a := []*typedYAMLValue{}
+
+		if err := unmarshal(&a); err != nil {
+			return err
+		}
+
+		arr := make([]interface{}, len(a))
+
+		for i, e := range a {
+			arr[i] = e.get()
+		}
+
+		v.value = arr
+	case int, int64, uint64, float64:
+		// numbers decoded as strings keep their literal text
+		var literal string
+
+		if err := unmarshal(&literal); err != nil {
+			return err
+		}
+
+		if jsonNumberFormat.MatchString(literal) {
+			v.value = json.Number(literal)
+			return nil
+		}
+
+		b, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("unsupported number %s: %w", literal, err)
+		}
+
+		v.value = json.Number(b)
+	default:
+		v.value = value
+	}
+
+	return nil
+}
+
+// get returns the decoded value, null values are decoded as nil pointers.
+func (v *typedYAMLValue) get() interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return v.value
+}
+
+// setParams takes the document path from the decoded object, when the path
+// key is set, and flattens the rest of it.
+func (d *importDocument) setParams(obj map[string]interface{}, typed bool, pathKey string) error {
+	if pathKey != "" {
+		if p, ok := obj[pathKey].(string); ok {
+			d.Path = p
+			delete(obj, pathKey)
+		}
+	}
+
+	if typed {
+		d.Params = map[string]interface{}{}
+		flattenObjects(obj, "", d.Params)
+
+		return nil
+	}
+
+	params, err := flatten.Flatten(obj, "", flatten.PathStyle)
+	if err != nil {
+		return fmt.Errorf("%s: failed to flatten input: %w", d, err)
+	}
+
+	d.Params = params
 
 	return nil
 }
+
+// flattenObjects flattens nested objects using path style keys, while other
+// values, including arrays, are kept as they are.
+func flattenObjects(data map[string]interface{}, prefix string, out map[string]interface{}) {
+	for k, v := range data {
+		key := path.Join(prefix, k)
+
+		if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+			flattenObjects(m, key, out)
+			continue
+		}
+
+		out[key] = v
+	}
+}
+
+func importFromCsv(in io.Reader) (map[string]interface{}, error) {
+	// CSV like:
+	// param1,value1
+	csvReader := csv.NewReader(in)
+	csvReader.FieldsPerRecord = 2 //nolint:gomnd
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode input as CSV: %w", err)
+	}
+
+	params := make(map[string]interface{}, len(records))
+
+	for _, record := range records {
+		params[record[0]] = record[1]
+	}
+
+	return params, nil
+}
+
+func importFromTsv(in io.Reader) (map[string]interface{}, error) {
+	// TSV like:
+	// param1	value1
+	return scanImportLines(in, func(line string) (string, string, error) {
+		kv := strings.SplitN(line, "\t", 2) //nolint:gomnd
+		if len(kv) != 2 {                   //nolint:gomnd
+			return "", "", errors.New("expected tab separated key and value")
+		}
+
+		return kv[0], kv[1], nil
+	})
+}
+
+func importFromEnvFile(in io.Reader, keyPrefix string) (map[string]interface{}, error) {
+	// Env like:
+	// KEY="val"
+	// export OTHER='otherval'
+	return scanImportLines(in, func(line string) (string, string, error) {
+		if strings.HasPrefix(line, "#") {
+			return "", "", nil
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		kv := strings.SplitN(line, "=", 2) //nolint:gomnd
+		if len(kv) != 2 {                  //nolint:gomnd
+			return "", "", errors.New("expected KEY=value")
+		}
+
+		key := strings.TrimSpace(kv[0])
+
+		if keyPrefix != "" {
+			if !strings.HasPrefix(key, keyPrefix) {
+				return "", "", fmt.Errorf("expected key with %s prefix", keyPrefix)
+			}
+
+			key = strings.TrimPrefix(key, keyPrefix)
+		}
+
+		value, err := unquoteImportValue(strings.TrimSpace(kv[1]))
+
+		return strings.ToLower(key), value, err
+	})
+}
+
+func importFromTfvars(in io.Reader) (map[string]interface{}, error) {
+	// Terraform Variables like:
+	// key = "val"
+	return scanImportLines(in, func(line string) (string, string, error) {
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			return "", "", nil
+		}
+
+		kv := strings.SplitN(line, "=", 2) //nolint:gomnd
+		if len(kv) != 2 {                  //nolint:gomnd
+			return "", "", errors.New("expected key = value")
+		}
+
+		value, err := unquoteImportValue(strings.TrimSpace(kv[1]))
+
+		return strings.TrimSpace(kv[0]), value, err
+	})
+}
+
+func importFromProperties(in io.Reader) (map[string]interface{}, error) {
+	// Java properties like:
+	// key=value
+	// key: value
+	// key value
+	params := map[string]interface{}{}
+
+	scanner := newImportScanner(in)
+	lineNumber := 0
+
+	var logical strings.Builder
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+
+		if logical.Len() == 0 && (line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!")) {
+			continue
+		}
+
+		// odd number of trailing backslashes continues the line
+		trailing := len(line) - len(strings.TrimRight(line, "\\"))
+		if trailing%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+
+		logical.WriteString(line)
+
+		key, value, err := splitProperty(logical.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode line %d: %w", lineNumber, err)
+		}
+
+		params[key] = value
+
+		logical.Reset()
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if logical.Len() > 0 {
+		key, value, err := splitProperty(logical.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode line %d: %w", lineNumber, err)
+		}
+
+		params[key] = value
+	}
+
+	return params, nil
+}
+
+// splitProperty splits logical line of properties file into unescaped key and
+// value. Key ends at first unescaped `=`, `:` or whitespace.
+func splitProperty(line string) (string, string, error) {
+	end := len(line)
+
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+
+		if strings.ContainsRune("=: \t\f", rune(line[i])) {
+			end = i
+			break
+		}
+	}
+
+	key, err := propertyUnescape(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":") {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	value, err := propertyUnescape(rest)
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, value, nil
+}
+
+func propertyUnescape(s string) (string, error) {
+	var sb strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c != '\\' || i == len(s)-1 {
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape in `%s`", s)
+			}
+
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 32) //nolint:gomnd
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape in `%s`: %w", s, err)
+			}
+
+			sb.WriteRune(rune(r))
+
+			i += 4
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// maxImportLineSize is the longest line accepted in line based input, which
+// can hold certificates or JSON documents.
+const maxImportLineSize = 16 * 1024 * 1024
+
+// newImportScanner returns scanner of input lines up to maxImportLineSize.
+func newImportScanner(in io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, maxImportLineSize)
+
+	return scanner
+}
+
+// scanImportLines parses line based input, skipping empty lines. The parse
+// function returns an empty key for lines which should be skipped.
+func scanImportLines(in io.Reader, parse func(line string) (string, string, error)) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+
+	scanner := newImportScanner(in)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		key, value, err := parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode line %d: %w", lineNumber, err)
+		}
+
+		if key != "" {
+			params[key] = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return params, nil
+}
+
+// unquoteImportValue removes quotes around the value. Double quoted values
+// are unescaped, single quoted ones are taken literally.
+func unquoteImportValue(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`): //nolint:gomnd
+		return doubleQuoteUnescape(value[1 : len(value)-1]), nil
+	case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"): //nolint:gomnd
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'"):
+		return "", errors.New("unterminated quoted value")
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/sicc/pkg/environ"
+	"github.com/zbiljic/sicc/store"
+)
+
+func withNameMapper(export func(map[string]string, environ.NameMapper, io.Writer) error) func(map[string]string, io.Writer) error {
+	return func(params map[string]string, w io.Writer) error {
+		return export(params, environ.NameMapper{}, w)
+	}
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	params := map[string]string{
+		"db_user":     "admin",
+		"db_password": `p"a$s\w'o!rd` + "\n`x`",
+		"empty_ish":   " spaced ",
+	}
+
+	tests := []struct {
+		name   string
+		export func(map[string]string, io.Writer) error
+		parse  func(io.Reader) (map[string]interface{}, error)
+	}{
+		{"csv", exportAsCsv, importFromCsv},
+		{"dotenv", withNameMapper(exportAsEnvFile), func(in io.Reader) (map[string]interface{}, error) { return importFromEnvFile(in, "") }},
+		{"tfvars", exportAsTfvars, importFromTfvars},
+		{"tfenvvars", withNameMapper(exportAsTfEnvVars), func(in io.Reader) (map[string]interface{}, error) { return importFromEnvFile(in, "TF_VAR_") }},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			err := test.export(params, buf)
+			assert.Nil(t, err)
+
+			imported, err := test.parse(buf)
+			assert.Nil(t, err)
+			assert.Len(t, imported, len(params))
+
+			for k, v := range params {
+				assert.Equal(t, v, imported[k], k)
+			}
+		})
+	}
+}
+
+func TestImportFromEnvFile(t *testing.T) {
+	input := `
+# comment
+export DB_USER=admin
+DB_PASSWORD='literal\n'
+DB_HOST="db\nhost"
+`
+
+	params, err := importFromEnvFile(strings.NewReader(input), "")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"db_user":     "admin",
+		"db_password": `literal\n`,
+		"db_host":     "db\nhost",
+	}, params)
+
+	_, err = importFromEnvFile(strings.NewReader("A=1\nINVALID\n"), "")
+	assert.EqualError(t, err, "failed to decode line 2: expected KEY=value")
+}
+
+func TestImportFromEnvFileLongLine(t *testing.T) {
+	value := strings.Repeat("x", 128*1024)
+
+	params, err := importFromEnvFile(strings.NewReader("CERT="+value+"\n"), "")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"cert": value}, params)
+}
+
+func TestImportFromProperties(t *testing.T) {
+	input := `
+# comment
+! other comment
+db.user=admin
+db.password : secret
+db.host localhost
+multi = first \
+        second
+escaped\=key = A\tB
+`
+
+	params, err := importFromProperties(strings.NewReader(input))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"db.user":     "admin",
+		"db.password": "secret",
+		"db.host":     "localhost",
+		"multi":       "first second",
+		"escaped=key": "A\tB",
+	}, params)
+}
+
+func TestImportFormatFromFileName(t *testing.T) {
+	for file, format := range map[string]string{
+		"config.json":            "",
+		"config.yml":             "",
+		"-":                      "",
+		"params.csv":             "csv",
+		"params.TSV":             "tsv",
+		".env":                   "dotenv",
+		".env.production":        "dotenv",
+		"prod.env":               "dotenv",
+		"terraform.tfvars":       "tfvars",
+		"application.properties": "properties",
+	} {
+		assert.Equal(t, format, importFormatFromFileName(file), file)
+	}
+}
+
+func TestImportTypedRoundTrip(t *testing.T) {
+	input := `{"app":{"port":8080,"debug":true,"name":"api","zip":"01234","version":"1.5","hosts":["a","b"],"ratio":0.5,"quoted":"\"x\""}}`
+
+	documents, err := importFromYAMLOrJSON(strings.NewReader(input), true, "")
+	assert.Nil(t, err)
+	assert.Len(t, documents, 1)
+
+	params := map[string]string{}
+	types := map[string]string{}
+
+	for k, v := range documents[0].Params {
+		params[k], types[k], err = encodeTypedValue(v)
+		assert.Nil(t, err)
+	}
+
+	assert.Equal(t, "8080", params["app/port"])
+	assert.Equal(t, "number", types["app/port"])
+	assert.Equal(t, "true", params["app/debug"])
+	assert.Equal(t, "boolean", types["app/debug"])
+	assert.Equal(t, "api", params["app/name"])
+	assert.Equal(t, "1.5", params["app/version"])
+	assert.Equal(t, "", types["app/version"])
+	assert.Equal(t, `"x"`, params["app/quoted"])
+	assert.Equal(t, `["a","b"]`, params["app/hosts"])
+	assert.Equal(t, "array", types["app/hosts"])
+
+	buf := &bytes.Buffer{}
+
+	err = exportAsJSON(params, types, buf)
+	assert.Nil(t, err)
+	assert.JSONEq(t, input, buf.String())
+
+	// untyped export sees values as they are
+	buf.Reset()
+
+	err = exportAsJSON(params, nil, buf)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"app":{"port":"8080","debug":"true","name":"api","zip":"01234","version":"1.5","hosts":"[\"a\",\"b\"]","ratio":"0.5","quoted":"\"x\""}}`, buf.String())
+}
+
+func TestImportTypedNumbers(t *testing.T) {
+	inputs := map[string]string{
+		"json": `{"big":12345678901234567890,"float":1.0,"exp":1e3,"list":[1.0,12345678901234567890]}`,
+		"yaml": "big: 12345678901234567890\nfloat: 1.0\nexp: 1e3\nlist: [1.0, 12345678901234567890]\nhex: 0x1f\nempty: null\n",
+	}
+
+	for name, input := range inputs {
+		input := input
+		t.Run(name, func(t *testing.T) {
+			documents, err := importFromYAMLOrJSON(strings.NewReader(input), true, "")
+			assert.Nil(t, err)
+			assert.Len(t, documents, 1)
+
+			params := map[string]string{}
+			types := map[string]string{}
+
+			for k, v := range documents[0].Params {
+				params[k], types[k], err = encodeTypedValue(v)
+				assert.Nil(t, err)
+			}
+
+			// numbers are stored as written
+			assert.Equal(t, "12345678901234567890", params["big"])
+			assert.Equal(t, "number", types["big"])
+			assert.Equal(t, "1.0", params["float"])
+			assert.Equal(t, "1e3", params["exp"])
+			assert.Equal(t, "[1.0,12345678901234567890]", params["list"])
+
+			if name == "yaml" {
+				assert.Equal(t, "31", params["hex"])
+				assert.Equal(t, "null", params["empty"])
+				assert.Equal(t, "null", types["empty"])
+			}
+
+			buf := &bytes.Buffer{}
+
+			err = exportAsJSON(map[string]string{"big": params["big"], "float": params["float"], "list": params["list"]}, types, buf)
+			assert.Nil(t, err)
+			assert.Equal(t, `{"big":12345678901234567890,"float":1.0,"list":[1.0,12345678901234567890]}`+"\n", buf.String())
+		})
+	}
+
+	_, err := importFromYAMLOrJSON(strings.NewReader("inf: .inf\n"), true, "")
+	assert.Error(t, err)
+
+	_, err = importFromYAMLOrJSON(strings.NewReader("- a\n"), true, "")
+	assert.Error(t, err)
+}
+
+func TestImportTypeDescriptions(t *testing.T) {
+	assert.Equal(t, "sicc:type=number", withTypeDescription("", "number"))
+	assert.Equal(t, "Port sicc:type=number", withTypeDescription("Port sicc:type=boolean", "number"))
+	assert.Equal(t, "Port", withTypeDescription("Port sicc:type=number", ""))
+	assert.Equal(t, "Port", withTypeDescription("Port", ""))
+	assert.Equal(t, "number", typeFromDescription("Port sicc:type=number"))
+	assert.Equal(t, "", typeFromDescription("Port"))
+
+	value := func(v, description string) store.Value {
+		return store.Value{Value: &v, Meta: store.Metadata{Description: description}}
+	}
+
+	current := map[string]store.Value{
+		"/app/debug": value("true", "Debug mode"),
+		"/app/port":  value("8080", "Port sicc:type=number"),
+		"/app/name":  value("api", "Name"),
+	}
+
+	desired := map[string]store.Value{
+		"/app/debug": value("true", ""),
+		"/app/port":  value("8080", ""),
+		"/app/name":  value("api", ""),
+		"/app/new":   value("1", ""),
+	}
+
+	setTypeDescriptions(desired, current, map[string]string{"/app/debug": "boolean", "/app/port": "number", "/app/new": "number"})
+	assert.Equal(t, "Debug mode sicc:type=boolean", desired["/app/debug"].Meta.Description)
+	assert.Equal(t, "Port sicc:type=number", desired["/app/port"].Meta.Description)
+	assert.Equal(t, "Name", desired["/app/name"].Meta.Description)
+	assert.Equal(t, "sicc:type=number", desired["/app/new"].Meta.Description)
+
+	plan := syncPlan(current, desired, false, nil, planMetadata{Secure: true, Description: true})
+	assert.Equal(t, []string{"/app/debug"}, plan.Changed)
+	assert.Equal(t, []string{"/app/name", "/app/port"}, plan.Unchanged)
+
+	// untyped import removes the recorded type, keeping the description
+	setTypeDescriptions(desired, current, nil)
+	assert.Equal(t, "Port", desired["/app/port"].Meta.Description)
+
+	plan = syncPlan(current, desired, false, nil, planMetadata{Secure: true, Description: true})
+	assert.Equal(t, []string{"/app/port"}, plan.Changed)
+}
+
+func TestImportMultipleDocuments(t *testing.T) {
+	input := `__sicc_path: dev
+db:
+  host: dev-db
+---
+__sicc_path: /prod/app
+db:
+  host: prod-db
+---
+# empty document
+---
+db:
+  host: default-db
+path: /var/lib/app
+`
+
+	documents, err := importFromYAMLOrJSON(strings.NewReader(input), false, "__sicc_path")
+	assert.Nil(t, err)
+	assert.Len(t, documents, 3)
+
+	targets := []string{}
+
+	for _, document := range documents {
+		target, err := document.target("/app", true)
+		assert.Nil(t, err)
+
+		targets = append(targets, target)
+	}
+
+	assert.Equal(t, []string{"/app/dev", "/prod/app", "/app"}, targets)
+	assert.Equal(t, map[string]interface{}{"db/host": "prod-db"}, documents[1].Params)
+	assert.Equal(t, map[string]interface{}{"db/host": "default-db", "path": "/var/lib/app"}, documents[2].Params)
+	assert.Equal(t, 11, documents[2].Line)
+
+	// outside paths are rejected unless allowed
+	_, err = documents[1].target("/app", false)
+	assert.Error(t, err)
+
+	target, err := documents[0].target("/app", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "/app/dev", target)
+
+	_, err = importDocument{Path: "../prod"}.target("/app", false)
+	assert.Error(t, err)
+
+	_, err = importDocument{Path: "/application"}.target("/app", false)
+	assert.Error(t, err)
+
+	// path key is not used unless set
+	documents, err = importFromYAMLOrJSON(strings.NewReader("path: /var/lib/app\nname: api\n"), false, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "", documents[0].Path)
+	assert.Equal(t, map[string]interface{}{"path": "/var/lib/app", "name": "api"}, documents[0].Params)
+
+	_, err = importFromYAMLOrJSON(strings.NewReader("a: 1\n---\nb: 1\n\tc: 2\n"), false, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "document 2 (line 3)")
+	assert.Contains(t, err.Error(), "yaml: line 4:")
+
+	_, err = importFromYAMLOrJSON(strings.NewReader("{\"a\": 1}\n{\"b\": "), false, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "document 2")
+}
+
+func TestSecretKeyMatcher(t *testing.T) {
+	m, err := newSecretKeyMatcher([]string{"*password*", "tls/*", "/prod/app/api_key"}, []string{`(^|/)token$`})
+	assert.Nil(t, err)
+
+	for key, secure := range map[string]bool{
+		"db/password":      true,
+		"db/password_hash": true,
+		"db/host":          false,
+		"tls/cert":         true,
+		"tls/ca/cert":      false,
+		"api_key":          true,
+		"github/token":     true,
+		"github/token_url": false,
+	} {
+		assert.Equal(t, secure, m.match(key, "/prod/app/"+key), key)
+	}
+
+	_, err = newSecretKeyMatcher([]string{"[a-"}, nil)
+	assert.Error(t, err)
+
+	_, err = newSecretKeyMatcher(nil, []string{"("})
+	assert.Error(t, err)
+}
+
+func TestSecretKeysRegexFlag(t *testing.T) {
+	defer func() { importParameters.SecretKeysRegexp = []string{} }()
+
+	err := importCmd.Flags().Parse([]string{"--secret-keys-regex", `^key{1,3}$`, "--secret-keys-regex", `token$`})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`^key{1,3}$`, `token$`}, importParameters.SecretKeysRegexp)
+}
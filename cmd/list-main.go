@@ -48,14 +48,17 @@ func runList(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get configuration store: %w", err)
 	}
 
-	configs, err := configStore.List(prefixPath, listParameters.WithValues)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	configs, err := configStore.List(ctx, prefixPath, listParameters.WithValues)
 	if err != nil {
 		return fmt.Errorf("failed to list store contents (%s): %w", prefixPath, err)
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, '\t', 0)
 
-	fmt.Fprint(w, "Key\tVersion\tLastModified\tUser")
+	fmt.Fprint(w, "Key\tVersion\tLastModified\tUser\tKMSKey")
 
 	if listParameters.WithValues {
 		fmt.Fprint(w, "\tValue")
@@ -78,11 +81,12 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	for _, config := range configs {
-		fmt.Fprintf(w, "%s\t%d\t%s\t%s",
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s",
 			stripPrefix(config.Meta.Key, prefixPath),
 			config.Meta.Version,
 			config.Meta.LastModifiedDate.Local().Format(shortTimeFormat),
 			config.Meta.LastModifiedUser,
+			config.Meta.KeyID,
 		)
 
 		if listParameters.WithValues {
@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -27,16 +31,35 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&globalBackend, "backend", "b", "ssm", `Backend to use
 	null: no-op
 	ssm: SSM Parameter Store
+	secretsmanager: AWS Secrets Manager
+	file: local JSON or YAML file
+	encrypted-file: local file encrypted with passphrase (SICC_PASSPHRASE) or key file
+	vault: HashiCorp Vault KV v2 (VAULT_ADDR, VAULT_TOKEN or SICC_VAULT_ROLE_ID and SICC_VAULT_SECRET_ID)
 `)
 	rootCmd.PersistentFlags().IntVarP(&globalNumRetries, "retries", "r", defaultNumRetries,
-		"For SSM, the number of retries to make before giving up")
+		"For SSM and Secrets Manager, the number of retries to make before giving up")
+	rootCmd.PersistentFlags().DurationVarP(&globalTimeout, "timeout", "", 0,
+		"Maximum duration of backend operations, e.g. 30s or 5m (default is no timeout)")
+	rootCmd.PersistentFlags().BoolVarP(&globalSSMLegacyVersions, "ssm-legacy-versions", "", false,
+		"For SSM, keep version numbers in parameter description (compatibility with parameters written by older versions)")
+	rootCmd.PersistentFlags().StringVarP(&globalKMSKey, "kms-key", "", "",
+		"For SSM, the KMS key used to encrypt secret values (default is account's alias/aws/ssm)")
+	rootCmd.PersistentFlags().StringToStringVarP(&globalKMSKeyMap, "kms-key-map", "", map[string]string{},
+		"For SSM, the KMS keys used for specific prefixes, e.g. /prod=alias/prod (longest prefix wins)\n"+
+			"Set by this flag or SICC_KMS_KEY_MAP, as sicc has no config file")
+	rootCmd.PersistentFlags().StringVarP(&globalProfile, "profile", "", "",
+		"For SSM and Secrets Manager, the AWS shared configuration profile to use")
+	rootCmd.PersistentFlags().IntVarP(&globalRecoveryWindow, "recovery-window", "", store.DefaultRecoveryWindowInDays,
+		"For Secrets Manager, the number of days (7 to 30) deleted secrets can be restored (0 deletes immediately)")
+	rootCmd.PersistentFlags().StringVarP(&globalFile, "file", "", "",
+		"For file backends, the path of the file")
+	rootCmd.PersistentFlags().StringVarP(&globalKeyFile, "key-file", "", "",
+		"For encrypted-file backend, the path of the key file (used instead of passphrase)")
 }
 
 func registerBefore(cmd *cobra.Command, args []string) error {
 	// Update global flags (if anything changed from other sources).
-	updateGlobals()
-
-	return nil
+	return updateGlobals()
 }
 
 // Execute adds all child commands to the root command sets flags appropriately.
@@ -51,8 +74,63 @@ func Execute() {
 	}
 }
 
+// commandContext returns context for backend operations of a command. It is
+// canceled on interrupt or termination signal, or when global timeout passes.
+// It should be created once per command, and passed down to functions doing
+// backend operations.
+func commandContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if globalTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+
+		ctx, cancelTimeout = context.WithTimeout(ctx, globalTimeout)
+
+		cancelParent := cancel
+		cancel = func() {
+			cancelTimeout()
+			cancelParent()
+		}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-sigChan:
+			if globalVerbose {
+				fmt.Fprintf(os.Stderr, "%s: received %s, canceling\n", AppName, sig)
+			}
+
+			cancel()
+		case <-ctx.Done():
+		}
+
+		// restore default behavior, so repeated signal terminates immediately
+		signal.Stop(sigChan)
+	}()
+
+	return ctx, cancel
+}
+
+// backendConfig selects the backend and its location.
+type backendConfig struct {
+	Backend string
+	File    string
+	Profile string
+}
+
 func getConfigurationStore() (store.Store, error) {
-	backend := strings.ToLower(globalBackend)
+	return newConfigurationStore(backendConfig{
+		Backend: globalBackend,
+		File:    globalFile,
+		Profile: globalProfile,
+	})
+}
+
+func newConfigurationStore(config backendConfig) (store.Store, error) {
+	backend := strings.ToLower(config.Backend)
 
 	var (
 		s   store.Store
@@ -63,10 +141,77 @@ func getConfigurationStore() (store.Store, error) {
 	case "null":
 		s = store.NewNullStore()
 	case "ssm":
-		s, err = store.NewSSMStore(globalNumRetries)
+		s, err = store.NewSSMStore(store.SSMStoreConfig{
+			NumRetries:     globalNumRetries,
+			LegacyVersions: globalSSMLegacyVersions,
+			KMSKey:         globalKMSKey,
+			KMSKeyByPrefix: globalKMSKeyMap,
+			Profile:        config.Profile,
+		})
+	case "secretsmanager":
+		s, err = store.NewSecretsManagerStore(store.SecretsManagerStoreConfig{
+			NumRetries:           globalNumRetries,
+			RecoveryWindowInDays: globalRecoveryWindow,
+			Profile:              config.Profile,
+		})
+	case "file":
+		s, err = store.NewFileStore(config.File)
+	case "encrypted-file":
+		var secret []byte
+
+		secret, err = encryptedFileSecret()
+		if err != nil {
+			return nil, err
+		}
+
+		s, err = store.NewEncryptedFileStore(config.File, secret)
+	case "vault":
+		s, err = store.NewVaultStore(store.VaultConfigFromEnv())
 	default:
 		return nil, fmt.Errorf("invalid backend `%s`", backend)
 	}
 
 	return s, err
 }
+
+// addTargetBackendFlags registers flags selecting the second store used by
+// commands working across stores.
+func addTargetBackendFlags(cmd *cobra.Command, config *backendConfig) {
+	cmd.Flags().StringVar(&config.Backend, "to-backend", "", "Backend of the target store (defaults to --backend)")
+	cmd.Flags().StringVar(&config.File, "to-file", "", "For file backends, the path of the target store file (defaults to --file)")
+	cmd.Flags().StringVar(&config.Profile, "to-profile", "", "For SSM and Secrets Manager, the AWS profile of the target store (defaults to --profile)")
+}
+
+// getTargetConfigurationStore returns the second store used by commands
+// working across stores. Settings which are not given default to the global
+// ones. Encrypted file stores share the key file or passphrase.
+func getTargetConfigurationStore(config backendConfig) (store.Store, error) {
+	if config.Backend == "" {
+		config.Backend = globalBackend
+	}
+
+	if config.File == "" {
+		config.File = globalFile
+	}
+
+	if config.Profile == "" {
+		config.Profile = globalProfile
+	}
+
+	return newConfigurationStore(config)
+}
+
+// encryptedFileSecret reads the secret for the encrypted file store, either
+// from the key file or the passphrase environment variable.
+func encryptedFileSecret() ([]byte, error) {
+	if globalKeyFile != "" {
+		secret, err := ioutil.ReadFile(globalKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+
+		return secret, nil
+	}
+
+	return []byte(os.Getenv(passphraseEnvVar)), nil
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// moveCmd represents the 'move' command
+var moveCmd = &cobra.Command{
+	Use:   "move <source> <destination>",
+	Short: "Move configuration(s) to another path",
+	Long: `Move configuration(s) to another path.
+
+Sources are deleted, including all their versions, only after all
+configurations were written to the destination.`,
+	Args: cobra.ExactArgs(2), //nolint:gomnd
+	RunE: runMove,
+}
+
+var moveParameters copyOptions
+
+func init() {
+	addCopyFlags(moveCmd, &moveParameters)
+	// add 'move' command to root command
+	rootCmd.AddCommand(moveCmd)
+}
+
+func runMove(cmd *cobra.Command, args []string) error {
+	return runCopyOrMove(args, moveParameters, true)
+}
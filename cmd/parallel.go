@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+const (
+	// minThrottleDelay is the delay used after the first throttled request
+	minThrottleDelay = 100 * time.Millisecond
+
+	// maxThrottleDelay is the longest delay between requests
+	maxThrottleDelay = 10 * time.Second
+
+	// maxThrottledAttempts is the number of attempts of a throttled request
+	maxThrottledAttempts = 10
+)
+
+// adaptiveBackoff slows down all workers while the backend is throttling
+// requests. The delay doubles with every throttled request, and halves with
+// every successful one.
+type adaptiveBackoff struct {
+	minDelay    time.Duration
+	maxDelay    time.Duration
+	maxAttempts int
+
+	mu    sync.Mutex
+	delay time.Duration
+}
+
+func newAdaptiveBackoff() *adaptiveBackoff {
+	return &adaptiveBackoff{
+		minDelay:    minThrottleDelay,
+		maxDelay:    maxThrottleDelay,
+		maxAttempts: maxThrottledAttempts,
+	}
+}
+
+// do calls fn, retrying it while it fails with throttling error.
+func (b *adaptiveBackoff) do(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		if err := b.wait(ctx); err != nil {
+			return err
+		}
+
+		err := fn()
+		if !store.IsThrottlingError(err) {
+			b.update(false)
+			return err
+		}
+
+		b.update(true)
+
+		if attempt >= b.maxAttempts {
+			return err
+		}
+	}
+}
+
+func (b *adaptiveBackoff) wait(ctx context.Context) error {
+	b.mu.Lock()
+	delay := b.delay
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return ctx.Err()
+	}
+
+	// add jitter, so workers do not retry all at once
+	delay += time.Duration(rand.Int63n(int64(delay)))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (b *adaptiveBackoff) update(throttled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case throttled && b.delay == 0:
+		b.delay = b.minDelay
+	case throttled:
+		b.delay *= 2
+		if b.delay > b.maxDelay {
+			b.delay = b.maxDelay
+		}
+	default:
+		b.delay /= 2
+		if b.delay < b.minDelay {
+			b.delay = 0
+		}
+	}
+}
+
+// errNotAttempted is reported for keys skipped after a failure.
+var errNotAttempted = errors.New("not attempted")
+
+// parallelApply calls fn for every key using a bounded number of workers,
+// retrying throttled calls with adaptive backoff and printing progress.
+// Unless continueOnError is set, no new calls are started after the first
+// failure. Failures are returned keyed by configuration key, including keys
+// which were not attempted, with errNotAttempted.
+func parallelApply(ctx context.Context, keys []string, concurrency int, continueOnError bool, verb string,
+	fn func(ctx context.Context, key string) error) map[string]error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	backoff := newAdaptiveBackoff()
+
+	var (
+		mu        sync.Mutex
+		done      int
+		failures  = map[string]error{}
+		attempted = map[string]bool{}
+		wg        sync.WaitGroup
+		stopOnce  sync.Once
+	)
+
+	// stop is closed to prevent starting new calls, while calls in progress
+	// are left to finish
+	stop := make(chan struct{})
+
+	work := make(chan string)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for key := range work {
+				select {
+				case <-stop:
+					// dispatched after the failure
+					continue
+				default:
+				}
+
+				err := backoff.do(ctx, func() error {
+					return fn(ctx, key)
+				})
+
+				mu.Lock()
+
+				done++
+				attempted[key] = true
+
+				if err != nil {
+					failures[key] = err
+
+					fmt.Printf("[%d/%d] Failed %s `%s`: %v\n", done, len(keys), verb, key, err)
+
+					if !continueOnError {
+						stopOnce.Do(func() { close(stop) })
+					}
+				} else {
+					fmt.Printf("[%d/%d] %s `%s`\n", done, len(keys), verb, key)
+				}
+
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, key := range keys {
+		select {
+		case work <- key:
+		case <-stop:
+			break dispatch
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+
+	close(work)
+	wg.Wait()
+
+	for _, key := range keys {
+		if !attempted[key] {
+			failures[key] = errNotAttempted
+		}
+	}
+
+	return failures
+}
+
+// printFailures prints summary of failed operations, and of operations which
+// were not attempted.
+func printFailures(failures map[string]error) {
+	var failed, notAttempted []string
+
+	for k, err := range failures {
+		if errors.Is(err, errNotAttempted) {
+			notAttempted = append(notAttempted, k)
+		} else {
+			failed = append(failed, k)
+		}
+	}
+
+	sort.Strings(failed)
+	sort.Strings(notAttempted)
+
+	fmt.Printf("Failed %d configurations:\n", len(failed))
+
+	for _, k := range failed {
+		fmt.Printf("  %s: %v\n", k, failures[k])
+	}
+
+	if len(notAttempted) > 0 {
+		fmt.Printf("Not attempted %d configurations:\n", len(notAttempted))
+
+		for _, k := range notAttempted {
+			fmt.Printf("  %s\n", k)
+		}
+	}
+}
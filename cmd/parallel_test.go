@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveBackoff(t *testing.T) {
+	b := &adaptiveBackoff{minDelay: time.Millisecond, maxDelay: 4 * time.Millisecond, maxAttempts: 5}
+
+	throttling := awserr.New("ThrottlingException", "Rate exceeded", nil)
+
+	calls := 0
+	err := b.do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("failed to put: %w", throttling)
+		}
+
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, time.Millisecond, b.delay)
+
+	b.update(true)
+	b.update(true)
+	b.update(true)
+	assert.Equal(t, 4*time.Millisecond, b.delay)
+
+	calls = 0
+	err = b.do(context.Background(), func() error {
+		calls++
+		return throttling
+	})
+	assert.Equal(t, throttling, err)
+	assert.Equal(t, 5, calls)
+
+	other := errors.New("other")
+	calls = 0
+	err = b.do(context.Background(), func() error {
+		calls++
+		return other
+	})
+	assert.Equal(t, other, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestParallelApply(t *testing.T) {
+	keys := []string{}
+	for i := 0; i < 20; i++ {
+		keys = append(keys, fmt.Sprintf("/test/key%02d", i))
+	}
+
+	var calls int32
+
+	failures := parallelApply(context.Background(), keys, 4, true, "Importing", func(ctx context.Context, key string) error {
+		atomic.AddInt32(&calls, 1)
+
+		if key == "/test/key05" {
+			return errors.New("failed")
+		}
+
+		return nil
+	})
+	assert.Equal(t, int32(20), calls)
+	assert.Len(t, failures, 1)
+	assert.EqualError(t, failures["/test/key05"], "failed")
+
+	calls = 0
+
+	failures = parallelApply(context.Background(), keys, 1, false, "Importing", func(ctx context.Context, key string) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("failed")
+	})
+	assert.Equal(t, int32(1), calls)
+	assert.Len(t, failures, 20)
+	assert.EqualError(t, failures["/test/key00"], "failed")
+
+	for _, key := range keys[1:] {
+		assert.Equal(t, errNotAttempted, failures[key])
+	}
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -22,14 +24,18 @@ var putCmd = &cobra.Command{
 }
 
 var putParameters struct {
-	Secret     bool
-	Singleline bool
+	Secret      bool
+	Singleline  bool
+	Description string
+	KMSKeyID    string
 }
 
 //nolint:lll
 func init() {
 	putCmd.Flags().BoolVar(&putParameters.Secret, "secret", false, "Add configuration as secret value")
 	putCmd.Flags().BoolVarP(&putParameters.Singleline, "singleline", "s", false, "Insert single line parameter (end with \\n)")
+	putCmd.Flags().StringVar(&putParameters.Description, "description", "", "Description of the configuration")
+	putCmd.Flags().StringVar(&putParameters.KMSKeyID, "kms-key-id", "", "KMS key used to encrypt the secret value, instead of the one configured with --kms-key or --kms-key-map")
 	// add 'put' command to root command
 	rootCmd.AddCommand(putCmd)
 }
@@ -41,6 +47,10 @@ func runPut(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
+	if putParameters.KMSKeyID != "" && !putParameters.Secret {
+		return errors.New("--kms-key-id requires --secret")
+	}
+
 	value := args[1]
 	if value == "-" {
 		// Read value from standard input
@@ -66,7 +76,8 @@ func runPut(cmd *cobra.Command, args []string) error {
 	val := store.Value{
 		Value: &value,
 		Meta: store.Metadata{
-			Secure: putParameters.Secret,
+			Description: putParameters.Description,
+			Secure:      putParameters.Secret,
 		},
 	}
 
@@ -75,6 +86,9 @@ func runPut(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get configuration store: %w", err)
 	}
 
+	ctx, cancel := commandContext()
+	defer cancel()
+
 	path, name := path.Split(configPathName)
 
 	parameterName := store.ParameterName{
@@ -82,11 +96,56 @@ func runPut(cmd *cobra.Command, args []string) error {
 		Name:          name,
 	}
 
-	// Skip writing configuration if value is unchanged
-	currentConfig, err := configStore.Get(parameterName, -1)
-	if err == nil && value == *currentConfig.Value && currentConfig.Meta.Secure == val.Meta.Secure {
-		return nil
+	val.Meta.KeyID = putParameters.KMSKeyID
+	if val.Meta.KeyID == "" {
+		val.Meta.KeyID = kmsKeyFor(configStore, parameterName, val.Meta.Secure)
+	}
+
+	return putConfig(ctx, configStore, parameterName, val, cmd.Flags().Changed("description"))
+}
+
+// putConfig writes the value, unless it would not change the current
+// configuration. Description of the current configuration is kept when no
+// description was given, the same with all stores.
+func putConfig(ctx context.Context, configStore store.Store, name store.ParameterName, val store.Value,
+	descriptionGiven bool) error {
+	currentConfig, err := configStore.Get(ctx, name, -1)
+	if err == nil {
+		if !descriptionGiven {
+			val.Meta.Description = currentConfig.Meta.Description
+		}
+
+		// Skip writing configuration if value is unchanged
+		if putUnchanged(currentConfig, val, descriptionGiven) {
+			return nil
+		}
+	}
+
+	return configStore.Put(ctx, name, val)
+}
+
+// putUnchanged reports whether writing the value would not change the current
+// configuration. Description is compared only when it was given, and KMS key
+// only when it is known.
+func putUnchanged(current, val store.Value, compareDescription bool) bool {
+	if *current.Value != *val.Value || current.Meta.Secure != val.Meta.Secure {
+		return false
+	}
+
+	if val.Meta.KeyID != "" && current.Meta.KeyID != val.Meta.KeyID {
+		return false
+	}
+
+	return !compareDescription || current.Meta.Description == val.Meta.Description
+}
+
+// kmsKeyFor returns the KMS key which the store uses to encrypt the secure
+// configuration, or empty string when it is not secure or the store does not
+// use KMS keys.
+func kmsKeyFor(configStore store.Store, name store.ParameterName, secure bool) string {
+	if s, ok := configStore.(store.KMSKeyStore); ok && secure {
+		return s.KMSKeyFor(name)
 	}
 
-	return configStore.Put(parameterName, val)
+	return ""
 }
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+func TestPutUnchanged(t *testing.T) {
+	value := "pass"
+	other := "other"
+
+	current := store.Value{Value: &value, Meta: store.Metadata{Secure: true, Description: "db password"}}
+
+	assert.True(t, putUnchanged(current, store.Value{Value: &value, Meta: store.Metadata{Secure: true}}, false))
+	assert.False(t, putUnchanged(current, store.Value{Value: &value, Meta: store.Metadata{Secure: true}}, true))
+	assert.True(t, putUnchanged(current, store.Value{Value: &value, Meta: store.Metadata{Secure: true, Description: "db password"}}, true))
+	assert.False(t, putUnchanged(current, store.Value{Value: &other, Meta: store.Metadata{Secure: true}}, false))
+	assert.False(t, putUnchanged(current, store.Value{Value: &value}, false))
+
+	current.Meta.KeyID = "alias/old"
+
+	assert.True(t, putUnchanged(current, store.Value{Value: &value, Meta: store.Metadata{Secure: true}}, false))
+	assert.True(t, putUnchanged(current, store.Value{Value: &value, Meta: store.Metadata{Secure: true, KeyID: "alias/old"}}, false))
+	assert.False(t, putUnchanged(current, store.Value{Value: &value, Meta: store.Metadata{Secure: true, KeyID: "alias/new"}}, false))
+}
+
+func TestPutConfigKeepsDescription(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemoryStore()
+	name := store.ParameterName{ParameterPath: "/test/db", Name: "password"}
+
+	pass1, pass2 := "pass1", "pass2"
+
+	err := putConfig(ctx, s, name, store.Value{Value: &pass1, Meta: store.Metadata{Description: "db password"}}, true)
+	assert.Nil(t, err)
+
+	// value only put keeps the description
+	err = putConfig(ctx, s, name, store.Value{Value: &pass2}, false)
+	assert.Nil(t, err)
+
+	config, err := s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass2", *config.Value)
+	assert.Equal(t, "db password", config.Meta.Description)
+
+	// given empty description clears it
+	err = putConfig(ctx, s, name, store.Value{Value: &pass2}, true)
+	assert.Nil(t, err)
+
+	config, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, config.Meta.Version)
+	assert.Equal(t, "", config.Meta.Description)
+}
@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/sicc/pkg/environ"
+	"github.com/zbiljic/sicc/store"
+)
+
+// renderCmd represents the 'render' command
+var renderCmd = &cobra.Command{
+	Use:   "render <file> [<output>]",
+	Short: "Render a file using configurations",
+	Long: `Render a file using configurations.
+
+The file is a Go template (https://golang.org/pkg/text/template/). Its data
+are configurations under the prefixes given with --prefix, keyed by names
+relative to the prefix, e.g. {{ index . "db/password" }}.
+
+Additional functions:
+  get "<key>"        value of the configuration, absolute paths are fetched
+                     from the store, others are looked up in the data
+  env "<name>"       value of the environment variable
+  b64enc <value>     base64 encoded value
+  toJson <value>     value encoded as JSON
+  quote <value>      value as double quoted string
+  default <d> <v>    the value, or d when the value is empty
+
+With --references, the file is not a template. Instead, references to
+configurations like sicc:///prod/db/password or ${sicc:/prod/db/password} are
+replaced with their values. Version of the configuration can be given like
+sicc:///prod/db/password#version=3, as in references resolved by 'exec'.
+Only referenced configurations are fetched, and all references which can not
+be resolved are reported.
+
+The result is written to standard output, unless output file is given.`,
+	Args: cobra.RangeArgs(1, 2), //nolint:gomnd
+	RunE: runRender,
+}
+
+var renderParameters struct {
+	Prefixes   []string
+	References bool
+}
+
+//nolint:lll
+func init() {
+	renderCmd.Flags().StringSliceVarP(&renderParameters.Prefixes, "prefix", "p", []string{}, "Prefixes of configurations used as template data")
+	renderCmd.Flags().BoolVar(&renderParameters.References, "references", false, "Replace references to configurations instead of rendering a Go template")
+	// add 'render' command to root command
+	rootCmd.AddCommand(renderCmd)
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	if renderParameters.References && len(renderParameters.Prefixes) > 0 {
+		return errors.New("--prefix can not be used together with --references")
+	}
+
+	configStore, err := getConfigurationStore()
+	if err != nil {
+		return fmt.Errorf("failed to get configuration store: %w", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	var out []byte
+
+	if renderParameters.References {
+		out, err = renderReferencesFile(ctx, configStore, args[0])
+	} else {
+		out, err = renderTemplateFile(ctx, configStore, renderParameters.Prefixes, args[0])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(args) < 2 { //nolint:gomnd
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+
+	if err := ioutil.WriteFile(args[1], out, 0600); err != nil {
+		return fmt.Errorf("failed to write output file (%s): %w", args[1], err)
+	}
+
+	return nil
+}
+
+// renderTemplateFile renders the template file completely, so that nothing is
+// written in case of failure.
+func renderTemplateFile(ctx context.Context, configStore store.Store, prefixes []string, file string) ([]byte, error) {
+	params, err := listParams(ctx, configStore, prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := parseTemplateFile(ctx, configStore, params, file)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderReferencesFile replaces all references to configurations in the file.
+func renderReferencesFile(ctx context.Context, configStore store.Store, file string) ([]byte, error) {
+	text, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file (%s): %w", file, err)
+	}
+
+	refs := findReferences(text)
+
+	values, err := resolveReferences(ctx, configStore, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	return replaceReferences(text, values), nil
+}
+
+// parseTemplateFile reads and parses the template file.
+func parseTemplateFile(ctx context.Context, configStore store.Store, params map[string]string, file string) (*template.Template, error) {
+	text, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file (%s): %w", file, err)
+	}
+
+	return newTemplate(ctx, configStore, params, filepath.Base(file), string(text))
+}
+
+// newTemplate parses the template text, with functions which look up
+// configurations in the params or fetch them from the store.
+func newTemplate(ctx context.Context, configStore store.Store, params map[string]string, name, text string) (*template.Template, error) {
+	fetched := make(map[string]string)
+
+	get := func(key string) (string, error) {
+		if !path.IsAbs(key) {
+			value, ok := params[key]
+			if !ok {
+				return "", fmt.Errorf("configuration `%s` not found", key)
+			}
+
+			return value, nil
+		}
+
+		if value, ok := fetched[key]; ok {
+			return value, nil
+		}
+
+		config, err := getFromStore(ctx, configStore, key)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch configuration `%s`: %w", key, err)
+		}
+
+		fetched[key] = *config.Value
+
+		return *config.Value, nil
+	}
+
+	funcs := template.FuncMap{
+		"get": get,
+		"env": os.Getenv,
+		"b64enc": func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+		"toJson": func(value interface{}) (string, error) {
+			b, err := json.Marshal(value)
+			return string(b), err
+		},
+		"quote": func(value interface{}) string {
+			return strconv.Quote(fmt.Sprint(value))
+		},
+		"default": func(def, value interface{}) interface{} {
+			if value == nil || value == "" {
+				return def
+			}
+
+			return value
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// referenceBatchSize is the number of referenced configurations fetched
+// concurrently. Stores have no batch get, so each of them is a separate Get.
+const referenceBatchSize = 10
+
+// reference is a reference to the configuration found in the file. Key is
+// the reference without environ.ReferencePrefix, e.g. /prod/db/password or
+// /prod/db/password#version=3.
+type reference struct {
+	Key  string
+	Line int
+}
+
+// unresolvedReferencesError is returned when referenced configurations do not
+// exist or can not be fetched.
+type unresolvedReferencesError struct {
+	References []reference
+	// Errors are errors of fetching referenced configurations, keyed by the
+	// configuration key. Configurations which do not exist have no error.
+	Errors map[string]error
+}
+
+func (e unresolvedReferencesError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d unresolved references:", len(e.References))
+
+	for _, ref := range e.References {
+		fmt.Fprintf(&b, "\n  line %d: %s", ref.Line, ref.Key)
+
+		if err, ok := e.Errors[ref.Key]; ok {
+			fmt.Fprintf(&b, ": %v", err)
+		}
+	}
+
+	return b.String()
+}
+
+func referenceKey(match [][]byte) string {
+	if len(match[1]) > 0 {
+		return string(match[1])
+	}
+
+	return string(match[2])
+}
+
+// findReferences returns all references in the text, in order of appearance.
+func findReferences(text []byte) []reference {
+	var refs []reference
+
+	for i, line := range bytes.Split(text, []byte("\n")) {
+		for _, match := range environ.ReferencePattern.FindAllSubmatch(line, -1) {
+			refs = append(refs, reference{Key: referenceKey(match), Line: i + 1})
+		}
+	}
+
+	return refs
+}
+
+// resolveReferences fetches values of referenced configurations, up to
+// referenceBatchSize of them concurrently. If any of them does not exist or
+// can not be fetched, e.g. because of invalid path, unresolvedReferencesError
+// listing all unresolved references is returned.
+func resolveReferences(ctx context.Context, configStore store.Store, refs []reference) (map[string]string, error) {
+	keys := []string{}
+	seen := map[string]bool{}
+
+	for _, ref := range refs {
+		if !seen[ref.Key] {
+			seen[ref.Key] = true
+			keys = append(keys, ref.Key)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		values  = make(map[string]string, len(keys))
+		missing = map[string]bool{}
+		errs    = map[string]error{}
+	)
+
+	for start := 0; start < len(keys); start += referenceBatchSize {
+		end := start + referenceBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		var wg sync.WaitGroup
+
+		for _, key := range keys[start:end] {
+			wg.Add(1)
+
+			go func(key string) {
+				defer wg.Done()
+
+				var config store.Value
+
+				name, version, err := environ.ParseReference(environ.ReferencePrefix + key)
+				if err == nil {
+					err = validateConfigPathName(name.ParameterPath + name.Name)
+				}
+
+				if err == nil {
+					config, err = configStore.Get(ctx, name, version)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch {
+				case errors.Is(err, store.ErrConfigNotFound):
+					missing[key] = true
+				case err != nil:
+					missing[key] = true
+					errs[key] = err
+				default:
+					values[key] = *config.Value
+				}
+			}(key)
+		}
+
+		wg.Wait()
+	}
+
+	if len(missing) > 0 {
+		unresolved := unresolvedReferencesError{}
+
+		if len(errs) > 0 {
+			unresolved.Errors = errs
+		}
+
+		for _, ref := range refs {
+			if missing[ref.Key] {
+				unresolved.References = append(unresolved.References, ref)
+			}
+		}
+
+		return nil, unresolved
+	}
+
+	return values, nil
+}
+
+// replaceReferences replaces references in the text with resolved values.
+func replaceReferences(text []byte, values map[string]string) []byte {
+	return environ.ReferencePattern.ReplaceAllFunc(text, func(match []byte) []byte {
+		return []byte(values[referenceKey(environ.ReferencePattern.FindSubmatch(match))])
+	})
+}
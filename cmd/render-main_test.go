@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemoryStore()
+
+	for k, v := range map[string]string{"user": "admin", "password": "p\"ss"} {
+		v := v
+		err := s.Put(ctx, store.ParameterName{ParameterPath: "/test/db", Name: k}, store.Value{Value: &v})
+		assert.Nil(t, err)
+	}
+
+	params, err := listParams(ctx, s, []string{"test"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"db/user": "admin", "db/password": "p\"ss"}, params)
+
+	os.Setenv("SICC_TEST_RENDER", "from-env")
+	defer os.Unsetenv("SICC_TEST_RENDER")
+
+	text := `user={{ get "db/user" }}
+password={{ get "/test/db/password" | quote }}
+index={{ index . "db/user" }}
+b64={{ get "db/user" | b64enc }}
+json={{ toJson . }}
+env={{ env "SICC_TEST_RENDER" }}
+default={{ env "SICC_TEST_RENDER_MISSING" | default "fallback" }}
+`
+
+	tmpl, err := newTemplate(ctx, s, params, "test", text)
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+
+	err = tmpl.Execute(&buf, params)
+	assert.Nil(t, err)
+	assert.Equal(t, `user=admin
+password="p\"ss"
+index=admin
+b64=YWRtaW4=
+json={"db/password":"p\"ss","db/user":"admin"}
+env=from-env
+default=fallback
+`, buf.String())
+
+	for _, text := range []string{`{{ get "db/missing" }}`, `{{ get "/test/db/missing" }}`} {
+		tmpl, err := newTemplate(ctx, s, params, "test", text)
+		assert.Nil(t, err)
+
+		err = tmpl.Execute(&buf, params)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "missing")
+	}
+
+	_, err = newTemplate(ctx, s, params, "test", `{{ get "db/user" `)
+	assert.Error(t, err)
+}
+
+func TestRenderReferences(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemoryStore()
+
+	for k, v := range map[string]string{"user": "admin", "password": "pass"} {
+		v := v
+		err := s.Put(ctx, store.ParameterName{ParameterPath: "/prod/db", Name: k}, store.Value{Value: &v})
+		assert.Nil(t, err)
+	}
+
+	text := []byte(`url: postgres://${sicc:/prod/db/user}:${sicc:/prod/db/password}@db
+password: sicc:///prod/db/password.
+`)
+
+	refs := findReferences(text)
+	assert.Equal(t, []reference{
+		{Key: "/prod/db/user", Line: 1},
+		{Key: "/prod/db/password", Line: 1},
+		{Key: "/prod/db/password", Line: 2},
+	}, refs)
+
+	values, err := resolveReferences(ctx, s, refs)
+	assert.Nil(t, err)
+	assert.Equal(t, `url: postgres://admin:pass@db
+password: pass.
+`, string(replaceReferences(text, values)))
+
+	text = []byte(`host: sicc:///prod/db/host
+user: ${sicc:/prod/db/user}
+port: ${sicc:/prod/db/port}
+`)
+
+	_, err = resolveReferences(ctx, s, findReferences(text))
+	assert.Equal(t, unresolvedReferencesError{References: []reference{
+		{Key: "/prod/db/host", Line: 1},
+		{Key: "/prod/db/port", Line: 3},
+	}}, err)
+	assert.Equal(t, "2 unresolved references:\n  line 1: /prod/db/host\n  line 3: /prod/db/port", err.Error())
+
+	text = []byte(`user: ${sicc:/prod/db/user}
+host: sicc:///prod/db/host
+bad: sicc:///prod//db
+other: ${sicc:/prod//other}
+`)
+
+	_, err = resolveReferences(ctx, s, findReferences(text))
+	assert.Error(t, err)
+
+	unresolved, ok := err.(unresolvedReferencesError)
+	assert.True(t, ok)
+	assert.Equal(t, []reference{
+		{Key: "/prod/db/host", Line: 2},
+		{Key: "/prod//db", Line: 3},
+		{Key: "/prod//other", Line: 4},
+	}, unresolved.References)
+	assert.Len(t, unresolved.Errors, 2)
+	assert.Contains(t, err.Error(), "line 4: /prod//other: ")
+
+	newPassword := "new-pass"
+	err = s.Put(ctx, store.ParameterName{ParameterPath: "/prod/db", Name: "password"}, store.Value{Value: &newPassword})
+	assert.Nil(t, err)
+
+	text = []byte(`old: sicc:///prod/db/password#version=1.
+new: ${sicc:/prod/db/password}
+`)
+
+	values, err = resolveReferences(ctx, s, findReferences(text))
+	assert.Nil(t, err)
+	assert.Equal(t, "old: pass.\nnew: new-pass\n", string(replaceReferences(text, values)))
+
+	_, err = resolveReferences(ctx, s, findReferences([]byte("${sicc:/prod/db/password#v3}")))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported fragment")
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+// rollbackCmd represents the 'rollback' command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <path>",
+	Short: "Rollback configuration(s) to a previous version",
+	Long: `Rollback configuration(s) to a previous version.
+
+The historical value is written as a new version, keeping its secure flag,
+description and KMS key. With --recursive all configurations under the prefix
+are rolled back to their state at the time given with --at. Configurations
+created after that time are skipped, unless --delete-missing is given, in
+which case they are deleted. Only configurations whose retained history
+starts with version 1 are deleted, as stores keep limited history, and others
+are reported and skipped. Configurations deleted since then can not be
+restored, as their history is deleted with them.`,
+	Args: cobra.ExactArgs(1), //nolint:gomnd
+	RunE: runRollback,
+}
+
+var rollbackParameters struct {
+	Version       int
+	At            string
+	Recursive     bool
+	DeleteMissing bool
+	DryRun        bool
+}
+
+//nolint:lll
+func init() {
+	rollbackCmd.Flags().IntVar(&rollbackParameters.Version, "to", -1, "The version number to rollback to")
+	rollbackCmd.Flags().StringVar(&rollbackParameters.At, "at", "", "Rollback to the state at the given time (RFC3339 or '2006-01-02 15:04:05' in local time)")
+	rollbackCmd.Flags().BoolVar(&rollbackParameters.Recursive, "recursive", false, "Rollback all configurations under the prefix (requires --at)")
+	rollbackCmd.Flags().BoolVar(&rollbackParameters.DeleteMissing, "delete-missing", false, "Delete configurations created after --at (requires --recursive)")
+	rollbackCmd.Flags().BoolVar(&rollbackParameters.DryRun, "dryrun", false, "Display result of rollback operation without actually changing configurations")
+	// add 'rollback' command to root command
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	configPathName := path.Join(pathSeparator, args[0])
+
+	if err := validateConfigPathName(configPathName); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	if (rollbackParameters.Version == -1) == (rollbackParameters.At == "") {
+		return errors.New("exactly one of --to or --at must be specified")
+	}
+
+	if rollbackParameters.Recursive && rollbackParameters.At == "" {
+		return errors.New("recursive rollback requires --at")
+	}
+
+	if rollbackParameters.DeleteMissing && !rollbackParameters.Recursive {
+		return errors.New("--delete-missing requires --recursive")
+	}
+
+	var at time.Time
+
+	if rollbackParameters.At != "" {
+		var err error
+
+		at, err = parseRollbackTime(rollbackParameters.At)
+		if err != nil {
+			return err
+		}
+	}
+
+	configStore, err := getConfigurationStore()
+	if err != nil {
+		return fmt.Errorf("failed to get configuration store: %w", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	if !rollbackParameters.Recursive {
+		return rollbackConfig(ctx, configStore, configPathName, rollbackParameters.Version, at, false)
+	}
+
+	configs, err := configStore.List(ctx, configPathName, false)
+	if err != nil {
+		return fmt.Errorf("failed to list store contents (%s): %w", configPathName, err)
+	}
+
+	for _, config := range configs {
+		err := rollbackConfig(ctx, configStore, config.Meta.Key, -1, at, rollbackParameters.DeleteMissing)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollbackConfig writes the historical value of the configuration as its new
+// version. The historical value is selected either by version, or as the
+// last version modified at or before the given time. Configuration which did
+// not exist at that time is deleted when deleteMissing is set, but only if its
+// retained history starts with the first version, so that it is known not to
+// have existed.
+func rollbackConfig(ctx context.Context, configStore store.Store, configPath string, version int, at time.Time,
+	deleteMissing bool) error {
+	parameterName := parameterNameFromPath(configPath)
+
+	history, err := configStore.History(ctx, parameterName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch configuration history `%s`: %w", configPath, err)
+	}
+
+	target, ok := rollbackTarget(history, version, at)
+	if !ok {
+		if version != -1 {
+			return fmt.Errorf("version %d of `%s` not found", version, configPath)
+		}
+
+		if !deleteMissing {
+			fmt.Printf("Skipping `%s`, it did not exist at %s\n", configPath, at.Local().Format(shortTimeFormat))
+			return nil
+		}
+
+		if len(history) == 0 || history[0].Meta.Version != 1 {
+			fmt.Printf("Skipping `%s`, its history before %s is not retained\n", configPath, at.Local().Format(shortTimeFormat))
+			return nil
+		}
+
+		fmt.Printf("Deleting `%s`, it did not exist at %s\n", configPath, at.Local().Format(shortTimeFormat))
+
+		if rollbackParameters.DryRun {
+			return nil
+		}
+
+		if err := configStore.Delete(ctx, parameterName); err != nil {
+			return fmt.Errorf("failed to delete configuration `%s`: %w", configPath, err)
+		}
+
+		return nil
+	}
+
+	current := history[len(history)-1]
+
+	val := store.Value{
+		Value: target.Value,
+		Meta: store.Metadata{
+			Description: target.Meta.Description,
+			Secure:      target.Meta.Secure,
+			KeyID:       target.Meta.KeyID,
+		},
+	}
+
+	if putUnchanged(current, val, true) {
+		fmt.Printf("Skipping `%s`, version %d is unchanged\n", configPath, target.Meta.Version)
+		return nil
+	}
+
+	fmt.Printf("Rolling back `%s` from version %d to version %d\n", configPath, current.Meta.Version, target.Meta.Version)
+
+	if rollbackParameters.DryRun {
+		return nil
+	}
+
+	if err := configStore.Put(ctx, parameterName, val); err != nil {
+		return fmt.Errorf("failed to rollback configuration `%s`: %w", configPath, err)
+	}
+
+	return nil
+}
+
+// rollbackTarget selects the version to rollback to from the history, which
+// is ordered oldest first.
+func rollbackTarget(history []store.Value, version int, at time.Time) (store.Value, bool) {
+	var (
+		target store.Value
+		found  bool
+	)
+
+	for _, config := range history {
+		if version != -1 {
+			if config.Meta.Version == version {
+				return config, true
+			}
+
+			continue
+		}
+
+		if !config.Meta.LastModifiedDate.After(at) {
+			target, found = config, true
+		}
+	}
+
+	return target, found
+}
+
+func parseRollbackTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(shortTimeFormat, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time `%s`, expected RFC3339 or '%s'", s, shortTimeFormat)
+	}
+
+	return t, nil
+}
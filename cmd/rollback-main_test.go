@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+func TestRollbackTarget(t *testing.T) {
+	start := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	history := []store.Value{}
+
+	for i, v := range []string{"v1", "v2", "v3"} {
+		v := v
+		history = append(history, store.Value{
+			Value: &v,
+			Meta: store.Metadata{
+				Version:          i + 1,
+				LastModifiedDate: start.Add(time.Duration(i) * time.Hour),
+			},
+		})
+	}
+
+	target, ok := rollbackTarget(history, 2, time.Time{})
+	assert.True(t, ok)
+	assert.Equal(t, "v2", *target.Value)
+
+	_, ok = rollbackTarget(history, 4, time.Time{})
+	assert.False(t, ok)
+
+	target, ok = rollbackTarget(history, -1, start.Add(90*time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, "v2", *target.Value)
+
+	target, ok = rollbackTarget(history, -1, start.Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, "v2", *target.Value)
+
+	_, ok = rollbackTarget(history, -1, start.Add(-time.Minute))
+	assert.False(t, ok)
+}
+
+func TestRollbackConfig(t *testing.T) {
+	ctx := context.Background()
+
+	s := store.NewMemoryStore()
+	name := store.ParameterName{ParameterPath: "/test/db", Name: "password"}
+
+	for _, v := range []string{"pass1", "pass2"} {
+		v := v
+		err := s.Put(ctx, name, store.Value{Value: &v, Meta: store.Metadata{Secure: true}})
+		assert.Nil(t, err)
+	}
+
+	err := rollbackConfig(ctx, s, "/test/db/password", 1, time.Time{}, false)
+	assert.Nil(t, err)
+
+	config, err := s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass1", *config.Value)
+	assert.Equal(t, 3, config.Meta.Version)
+	assert.True(t, config.Meta.Secure)
+
+	err = rollbackConfig(ctx, s, "/test/db/password", 5, time.Time{}, false)
+	assert.Error(t, err)
+
+	// versions differing only in KMS key are rolled back
+	pass := "pass1"
+
+	for _, keyID := range []string{"alias/one", "alias/two"} {
+		err = s.Put(ctx, name, store.Value{Value: &pass, Meta: store.Metadata{Secure: true, KeyID: keyID}})
+		assert.Nil(t, err)
+	}
+
+	err = rollbackConfig(ctx, s, "/test/db/password", 4, time.Time{}, false)
+	assert.Nil(t, err)
+
+	config, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, config.Meta.Version)
+	assert.Equal(t, "alias/one", config.Meta.KeyID)
+
+	before := time.Now().Add(-time.Hour)
+
+	err = rollbackConfig(ctx, s, "/test/db/password", -1, before, false)
+	assert.Nil(t, err)
+
+	_, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+
+	err = rollbackConfig(ctx, truncatedHistoryStore{s}, "/test/db/password", -1, before, true)
+	assert.Nil(t, err)
+
+	_, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+
+	err = rollbackConfig(ctx, s, "/test/db/password", -1, before, true)
+	assert.Nil(t, err)
+
+	_, err = s.Get(ctx, name, -1)
+	assert.Equal(t, store.ErrConfigNotFound, err)
+}
+
+// truncatedHistoryStore drops the first version from the history, like
+// stores which retain limited history.
+type truncatedHistoryStore struct {
+	store.Store
+}
+
+func (s truncatedHistoryStore) History(ctx context.Context, name store.ParameterName) ([]store.Value, error) {
+	history, err := s.Store.History(ctx, name)
+	if err != nil || len(history) == 0 {
+		return history, err
+	}
+
+	return history[1:], nil
+}
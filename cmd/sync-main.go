@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+// syncCmd represents the 'sync' command
+var syncCmd = &cobra.Command{
+	Use:   "sync <source-prefix> [<destination-prefix>]",
+	Short: "Make configurations in the destination match the source",
+	Long: `Make configurations in the destination match the source.
+
+The source is read from the store selected with the global flags, and the
+destination is the store selected with --to-backend, --to-file and
+--to-profile. The destination prefix defaults to the source prefix.
+
+The plan of changes is printed, and applied only with --apply. Secure flag and
+description are kept, while secure values are encrypted with the KMS key
+configured for the destination. Configurations differing only in metadata
+which the destination does not keep (e.g. Secrets Manager keeps neither) are
+not updated.`,
+	Args: cobra.RangeArgs(1, 2), //nolint:gomnd
+	RunE: runSync,
+}
+
+var syncParameters struct {
+	Apply      bool
+	Prune      bool
+	ShowValues bool
+	Target     backendConfig
+}
+
+//nolint:lll
+func init() {
+	syncCmd.Flags().BoolVar(&syncParameters.Apply, "apply", false, "Apply the plan (only print it by default)")
+	syncCmd.Flags().BoolVar(&syncParameters.Prune, "prune", false, "Delete destination configurations missing from the source")
+	syncCmd.Flags().BoolVar(&syncParameters.ShowValues, "show-values", false, "Show values of the configurations in the plan (masked by default)")
+	addTargetBackendFlags(syncCmd, &syncParameters.Target)
+	// add 'sync' command to root command
+	rootCmd.AddCommand(syncCmd)
+}
+
+//nolint:funlen
+func runSync(cmd *cobra.Command, args []string) error {
+	sourcePath := path.Join(pathSeparator, args[0])
+	destinationPath := sourcePath
+
+	if len(args) > 1 {
+		destinationPath = path.Join(pathSeparator, args[1])
+	}
+
+	for _, p := range []string{sourcePath, destinationPath} {
+		if err := validateConfigPathName(p); err != nil {
+			return fmt.Errorf("validation failed: %w", err)
+		}
+	}
+
+	sourceStore, err := getConfigurationStore()
+	if err != nil {
+		return fmt.Errorf("failed to get configuration store: %w", err)
+	}
+
+	destinationStore, err := getTargetConfigurationStore(syncParameters.Target)
+	if err != nil {
+		return fmt.Errorf("failed to get target configuration store: %w", err)
+	}
+
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	source, err := listRelativeValues(ctx, sourceStore, sourcePath)
+	if err != nil {
+		return err
+	}
+
+	// secure values are written with the key of the destination
+	for k, value := range source {
+		value.Meta = store.Metadata{
+			Description: value.Meta.Description,
+			Secure:      value.Meta.Secure,
+			KeyID:       kmsKeyFor(destinationStore, parameterNameFromPath(path.Join(destinationPath, k)), value.Meta.Secure),
+		}
+		source[k] = value
+	}
+
+	destination, err := listRelativeValues(ctx, destinationStore, destinationPath)
+	if err != nil {
+		return err
+	}
+
+	plan := syncPlan(destination, source, syncParameters.Prune, nil, planMetadataOf(destinationStore))
+
+	fmt.Printf("Plan: %d to create, %d to update, %d to delete\n", len(plan.Added), len(plan.Changed), len(plan.Removed))
+	printConfigDiff(os.Stdout, plan, rawValuesOf(destination), rawValuesOf(source), syncParameters.ShowValues)
+
+	if !syncParameters.Apply {
+		if !plan.empty() {
+			fmt.Println("Run with --apply to apply the plan")
+		}
+
+		return nil
+	}
+
+	for _, keys := range [][]string{plan.Added, plan.Changed} {
+		for _, k := range keys {
+			key := path.Join(destinationPath, k)
+
+			if err := destinationStore.Put(ctx, parameterNameFromPath(key), source[k]); err != nil {
+				return fmt.Errorf("failed to write configuration `%s`: %w", key, err)
+			}
+		}
+	}
+
+	for _, k := range plan.Removed {
+		key := path.Join(destinationPath, k)
+
+		if err := deleteFromStore(ctx, destinationStore, key); err != nil {
+			return fmt.Errorf("failed to delete configuration `%s`: %w", key, err)
+		}
+	}
+
+	fmt.Println("Plan applied")
+
+	return nil
+}
+
+// planMetadata selects metadata which is compared in the plan, besides
+// values.
+type planMetadata struct {
+	Secure      bool
+	Description bool
+}
+
+// planMetadataOf selects metadata kept by the store.
+func planMetadataOf(s store.Store) planMetadata {
+	return planMetadata{
+		Secure:      store.KeepsSecure(s),
+		Description: store.KeepsDescription(s),
+	}
+}
+
+// syncPlan computes changes needed for current configurations to match the
+// desired ones. Configurations which only differ in metadata selected by meta,
+// or in KMS key when the desired one is known, are updated. Removals are
+// included only when pruning, and never of keys which are seen in the input
+// although they are not desired (e.g. empty values).
+func syncPlan(current, desired map[string]store.Value, prune bool, seen map[string]bool,
+	meta planMetadata) configDiff {
+	plan := diffConfigs(rawValuesOf(current), rawValuesOf(desired))
+
+	unchanged := plan.Unchanged
+	plan.Unchanged = nil
+
+	for _, k := range unchanged {
+		if meta.changed(current[k].Meta, desired[k].Meta) {
+			plan.Changed = append(plan.Changed, k)
+			continue
+		}
+
+		plan.Unchanged = append(plan.Unchanged, k)
+	}
+
+	sort.Strings(plan.Changed)
+
+	removed := plan.Removed
+	plan.Removed = nil
+
+	for _, k := range removed {
+		if prune && !seen[k] {
+			plan.Removed = append(plan.Removed, k)
+		}
+	}
+
+	return plan
+}
+
+// changed reports whether the selected metadata, or the KMS key when the
+// desired one is known, differs.
+func (m planMetadata) changed(current, desired store.Metadata) bool {
+	return (m.Secure && current.Secure != desired.Secure) ||
+		(m.Description && current.Description != desired.Description) ||
+		(desired.KeyID != "" && current.KeyID != desired.KeyID)
+}
+
+// listRelativeValues lists configurations under the prefix, keyed by names
+// relative to the prefix.
+func listRelativeValues(ctx context.Context, configStore store.Store, prefixPath string) (map[string]store.Value, error) {
+	configs, err := configStore.List(ctx, prefixPath, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list store contents (%s): %w", prefixPath, err)
+	}
+
+	values := make(map[string]store.Value, len(configs))
+
+	for _, config := range configs {
+		values[stripPrefix(config.Meta.Key, prefixPath)] = config
+	}
+
+	return values, nil
+}
+
+func rawValuesOf(values map[string]store.Value) map[string]string {
+	params := make(map[string]string, len(values))
+
+	for k, v := range values {
+		params[k] = *v.Value
+	}
+
+	return params
+}
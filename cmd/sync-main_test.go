@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+func TestSyncPlan(t *testing.T) {
+	value := func(v string, secure bool) store.Value {
+		return store.Value{Value: &v, Meta: store.Metadata{Secure: secure}}
+	}
+
+	current := map[string]store.Value{
+		"db/user":     value("admin", false),
+		"db/password": value("pass", false),
+		"db/host":     value("old", false),
+		"extra":       value("x", false),
+	}
+	desired := map[string]store.Value{
+		"db/user":     value("admin", false),
+		"db/password": value("pass", true),
+		"db/host":     value("new", false),
+		"db/port":     value("5432", false),
+	}
+
+	meta := planMetadataOf(store.NewMemoryStore())
+	assert.Equal(t, planMetadata{Secure: true, Description: true}, meta)
+
+	plan := syncPlan(current, desired, false, nil, meta)
+	assert.Equal(t, []string{"db/port"}, plan.Added)
+	assert.Equal(t, []string{"db/host", "db/password"}, plan.Changed)
+	assert.Equal(t, []string{"db/user"}, plan.Unchanged)
+	assert.Empty(t, plan.Removed)
+
+	plan = syncPlan(current, desired, true, nil, meta)
+	assert.Equal(t, []string{"extra"}, plan.Removed)
+
+	plan = syncPlan(current, desired, true, map[string]bool{"extra": true}, meta)
+	assert.Empty(t, plan.Removed)
+
+	// secure flag is not compared when the destination does not keep it
+	meta = planMetadataOf(&store.SecretsManagerStore{})
+	assert.Equal(t, planMetadata{}, meta)
+
+	plan = syncPlan(current, desired, false, nil, meta)
+	assert.Equal(t, []string{"db/host"}, plan.Changed)
+	assert.Equal(t, []string{"db/password", "db/user"}, plan.Unchanged)
+}
+
+func TestSyncPlanDescription(t *testing.T) {
+	value := func(v, description string) store.Value {
+		return store.Value{Value: &v, Meta: store.Metadata{Description: description}}
+	}
+
+	current := map[string]store.Value{
+		"db/user":     value("admin", "user name"),
+		"db/password": value("pass", ""),
+	}
+	desired := map[string]store.Value{
+		"db/user":     value("admin", "user name"),
+		"db/password": value("pass", "password of the user"),
+	}
+
+	plan := syncPlan(current, desired, false, nil, planMetadata{Secure: true, Description: true})
+	assert.Equal(t, []string{"db/password"}, plan.Changed)
+	assert.Equal(t, []string{"db/user"}, plan.Unchanged)
+
+	plan = syncPlan(current, desired, false, nil, planMetadata{Secure: true})
+	assert.Empty(t, plan.Changed)
+}
+
+func TestSyncPlanKMSKey(t *testing.T) {
+	value := func(v, keyID string) store.Value {
+		return store.Value{Value: &v, Meta: store.Metadata{Secure: true, KeyID: keyID}}
+	}
+
+	current := map[string]store.Value{
+		"db/user":     value("admin", "alias/old"),
+		"db/password": value("pass", "alias/old"),
+		"db/host":     value("localhost", "alias/old"),
+	}
+	desired := map[string]store.Value{
+		"db/user":     value("admin", "alias/old"),
+		"db/password": value("pass", "alias/new"),
+		"db/host":     value("localhost", ""),
+	}
+
+	plan := syncPlan(current, desired, false, nil, planMetadata{Secure: true, Description: true})
+	assert.Equal(t, []string{"db/password"}, plan.Changed)
+	assert.Equal(t, []string{"db/host", "db/user"}, plan.Unchanged)
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"encoding/json"
+	"regexp"
 	"sort"
 	"strings"
 )
@@ -9,7 +11,7 @@ const doubleQuoteSpecialChars = "\\\n\r\"!$`"
 
 func stripPrefix(s, prefix string) string {
 	if strings.HasPrefix(s, prefix) {
-		return s[len(prefix)+1:]
+		return strings.TrimPrefix(s[len(prefix):], pathSeparator)
 	}
 
 	return s
@@ -46,3 +48,182 @@ func doubleQuoteEscape(line string) string {
 
 	return line
 }
+
+// shellQuote quotes the value for POSIX shell. Nothing is special inside
+// single quotes, so single quote ends the quoting, is escaped, and quoting
+// starts again.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes the value for fish shell, where backslash and single quote
+// have to be escaped inside single quotes.
+func fishQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "'", `\'`)
+
+	return "'" + value + "'"
+}
+
+// powerShellQuote quotes the value for PowerShell. Inside single quotes all
+// kinds of single quotes are escaped by doubling them.
+func powerShellQuote(value string) string {
+	var sb strings.Builder
+
+	sb.WriteRune('\'')
+
+	for _, c := range value {
+		switch c {
+		case '\'', '\u2018', '\u2019', '\u201a', '\u201b':
+			sb.WriteRune(c)
+		}
+
+		sb.WriteRune(c)
+	}
+
+	sb.WriteRune('\'')
+
+	return sb.String()
+}
+
+// doubleQuoteUnescape reverses doubleQuoteEscape.
+func doubleQuoteUnescape(line string) string {
+	var sb strings.Builder
+
+	escaped := false
+
+	for _, c := range line {
+		if !escaped && c == '\\' {
+			escaped = true
+			continue
+		}
+
+		if escaped {
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case '\\', '"', '!', '$', '`':
+			default:
+				sb.WriteRune('\\')
+			}
+
+			escaped = false
+		}
+
+		sb.WriteRune(c)
+	}
+
+	if escaped {
+		sb.WriteRune('\\')
+	}
+
+	return sb.String()
+}
+
+// parseKeyValuePairs parses comma separated list of `key=value` pairs.
+// Items without `=` are dropped.
+func parseKeyValuePairs(s string) map[string]string {
+	ret := map[string]string{}
+
+	for _, kv := range strings.Split(s, ",") {
+		s := strings.SplitN(kv, "=", 2) //nolint:gomnd
+
+		if len(s) != 2 { //nolint:gomnd
+			continue
+		}
+
+		ret[strings.TrimSpace(s[0])] = strings.TrimSpace(s[1])
+	}
+
+	return ret
+}
+
+// typeDescriptionPrefix marks the JSON type of the value of configurations
+// imported in typed mode, recorded at the end of the description, e.g.
+// `Port of the server sicc:type=number`.
+const typeDescriptionPrefix = "sicc:type="
+
+// typeDescriptionPattern matches the type recorded at the end of the
+// description.
+var typeDescriptionPattern = regexp.MustCompile(`(?:^|\s)` + typeDescriptionPrefix + `([a-z]+)$`)
+
+// encodeTypedValue encodes the value for typed mode, and returns its JSON
+// type. Strings, numbers and booleans are stored as they are, while arrays,
+// objects and null are stored as JSON. The type of strings is empty, as they
+// need no decoding.
+func encodeTypedValue(value interface{}) (string, string, error) {
+	var valueType string
+
+	switch v := value.(type) {
+	case string:
+		return v, "", nil
+	case bool:
+		valueType = "boolean"
+	case nil:
+		valueType = "null"
+	case []interface{}:
+		valueType = "array"
+	case map[string]interface{}:
+		valueType = "object"
+	default:
+		valueType = "number"
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(b), valueType, nil
+}
+
+// decodeTypedValue reverses encodeTypedValue. Values without type, or which
+// are not valid JSON, are returned as strings.
+func decodeTypedValue(s, valueType string) interface{} {
+	if valueType == "" {
+		return s
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(s))
+	// numbers keep their literal text
+	decoder.UseNumber()
+
+	var value interface{}
+
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return s
+	}
+
+	return value
+}
+
+// withTypeDescription returns the description with the value type recorded
+// at its end, replacing the type recorded before. The rest of the description
+// is kept, and without type only the recorded one is removed.
+func withTypeDescription(description, valueType string) string {
+	description = typeDescriptionPattern.ReplaceAllString(description, "")
+
+	if valueType == "" {
+		return description
+	}
+
+	if description == "" {
+		return typeDescriptionPrefix + valueType
+	}
+
+	return description + " " + typeDescriptionPrefix + valueType
+}
+
+// typeFromDescription returns the value type recorded in the description.
+func typeFromDescription(description string) string {
+	match := typeDescriptionPattern.FindStringSubmatch(description)
+	if match == nil {
+		return ""
+	}
+
+	return match[1]
+}
@@ -4,16 +4,16 @@ go 1.13
 
 require (
 	github.com/Jeffail/gabs/v2 v2.3.0
-	github.com/aws/aws-sdk-go v1.25.1
+	github.com/aws/aws-sdk-go v1.34.0
 	github.com/ghodss/yaml v1.0.0
 	github.com/jeremywohl/flatten v1.0.1
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/spf13/cast v1.3.0
 	github.com/spf13/cobra v0.0.5
 	github.com/spf13/pflag v1.0.5 // indirect
-	github.com/stretchr/testify v1.2.2
-	golang.org/x/net v0.0.0-20190628185345-da137c7871d7 // indirect
+	github.com/stretchr/testify v1.5.1
+	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550
 	golang.org/x/text v0.3.2 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
-	gopkg.in/yaml.v2 v2.2.7 // indirect
+	gopkg.in/yaml.v2 v2.2.7
 )
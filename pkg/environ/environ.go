@@ -1,7 +1,13 @@
 package environ
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/zbiljic/sicc/store"
@@ -79,8 +85,12 @@ func configKeyToEnvVarName(k string) string {
 }
 
 func normalizeEnvVarName(k string) string {
-	envVarName := strings.ToUpper(k)
-	envVarName = strings.ReplaceAll(envVarName, "/", "_")
+	return strings.ToUpper(replaceEnvVarNameChars(k))
+}
+
+// substitute `/`, `-` and `.` -> `_`, keeping case.
+func replaceEnvVarNameChars(k string) string {
+	envVarName := strings.ReplaceAll(k, "/", "_")
 	envVarName = strings.ReplaceAll(envVarName, "-", "_")
 	envVarName = strings.ReplaceAll(envVarName, ".", "_")
 	envVarName = strings.TrimPrefix(envVarName, "_")
@@ -88,21 +98,37 @@ func normalizeEnvVarName(k string) string {
 	return envVarName
 }
 
-// Load loads environment variables into 'e' from 's' given a prefix path.
-// Collisions will be populated with any keys that get overwritten.
-func (e *Environ) Load(s store.Store, prefixPath string, collisions *[]string) error {
-	return e.load(s, prefixPath, collisions)
+// Load loads environment variables into 'e' from 's' given a prefix path,
+// with names mapped by 'mapper'. Collisions will be populated with any keys
+// that get overwritten. Configurations with invalid mapped names are not
+// loaded, skipped will be populated with them unless it is nil.
+func (e *Environ) Load(ctx context.Context, s store.Store, mapper NameMapper, prefixPath string,
+	collisions *[]string, skipped *[]InvalidNameError) error {
+	return e.load(ctx, s, mapper, prefixPath, collisions, skipped)
 }
 
-func (e *Environ) load(s store.Store, prefixPath string, collisions *[]string) error {
-	rawValues, err := s.ListRaw(prefixPath)
+func (e *Environ) load(ctx context.Context, s store.Store, mapper NameMapper, prefixPath string,
+	collisions *[]string, skipped *[]InvalidNameError) error {
+	rawValues, err := s.ListRaw(ctx, prefixPath)
 	if err != nil {
 		return fmt.Errorf("failed to list store contents (%s): %w", prefixPath, err)
 	}
 
 	for _, rawValue := range rawValues {
 		key := strings.TrimPrefix(rawValue.Key, prefixPath)
-		envVarKey := configKeyToEnvVarName(key)
+
+		envVarKey, err := mapper.EnvVarName(key)
+
+		var invalid InvalidNameError
+		if errors.As(err, &invalid) {
+			if skipped != nil {
+				*skipped = append(*skipped, invalid)
+			}
+
+			continue
+		} else if err != nil {
+			return err
+		}
 
 		if e.IsSet(envVarKey) {
 			*collisions = append(*collisions, envVarKey)
@@ -114,22 +140,25 @@ func (e *Environ) load(s store.Store, prefixPath string, collisions *[]string) e
 	return nil
 }
 
-// LoadStrict loads all prefix paths from 's' in strict mode: env vars in 'e'
-// with value equal to 'valueExpected' are the only ones substituted.
+// LoadStrict loads all prefix paths from 's' in strict mode, with names mapped
+// by 'mapper': env vars in 'e' with value equal to 'valueExpected' are the only
+// ones substituted.
 // If there are any env vars in 's' that are also in 'e', but don't have their
 // value set to 'valueExpected' it returns an error.
-func (e *Environ) LoadStrict(s store.Store, valueExpected string, pristine bool, prefixPaths ...string) error {
-	return e.loadStrict(s, valueExpected, pristine, prefixPaths...)
+func (e *Environ) LoadStrict(ctx context.Context, s store.Store, mapper NameMapper, valueExpected string, pristine bool,
+	prefixPaths ...string) error {
+	return e.loadStrict(ctx, s, mapper, valueExpected, pristine, prefixPaths...)
 }
 
-func (e *Environ) loadStrict(s store.Store, valueExpected string, pristine bool, prefixPaths ...string) error {
+func (e *Environ) loadStrict(ctx context.Context, s store.Store, mapper NameMapper, valueExpected string, pristine bool,
+	prefixPaths ...string) error {
 	for _, prefixPath := range prefixPaths {
-		rawValues, err := s.ListRaw(prefixPath)
+		rawValues, err := s.ListRaw(ctx, prefixPath)
 		if err != nil {
 			return fmt.Errorf("failed to list store contents (%s): %w", prefixPath, err)
 		}
 
-		err = e.loadStrictOne(rawValues, valueExpected, pristine)
+		err = e.loadStrictOne(rawValues, mapper, prefixPath, valueExpected, pristine)
 		if err != nil {
 			return err
 		}
@@ -138,13 +167,14 @@ func (e *Environ) loadStrict(s store.Store, valueExpected string, pristine bool,
 	return nil
 }
 
-func (e *Environ) loadStrictOne(rawValues []store.RawValue, valueExpected string, pristine bool) error {
+func (e *Environ) loadStrictOne(rawValues []store.RawValue, mapper NameMapper, prefixPath, valueExpected string, pristine bool) error {
 	parentMap := e.Map()
 	parentExpects := map[string]struct{}{}
 
 	for k, v := range parentMap {
 		if v == valueExpected {
-			if k != normalizeEnvVarName(k) {
+			// mapped names may be unnormalized on purpose
+			if mapper.normalized() && k != normalizeEnvVarName(k) {
 				return ExpectedKeyUnnormalizedError{Key: k, ValueExpected: valueExpected}
 			}
 
@@ -155,7 +185,12 @@ func (e *Environ) loadStrictOne(rawValues []store.RawValue, valueExpected string
 	envVarKeysAdded := map[string]struct{}{}
 
 	for _, rawValue := range rawValues {
-		envVarKey := configKeyToEnvVarName(rawValue.Key)
+		key := strings.TrimPrefix(strings.TrimPrefix(rawValue.Key, prefixPath), "/")
+
+		envVarKey, custom, err := mapper.envVarName(key)
+		if err != nil {
+			return err
+		}
 
 		parentVal, parentOk := parentMap[envVarKey]
 		// skip injecting configurations that are not present in the parent
@@ -163,6 +198,10 @@ func (e *Environ) loadStrictOne(rawValues []store.RawValue, valueExpected string
 			continue
 		}
 
+		if err := checkName(key, envVarKey, custom); err != nil {
+			return err
+		}
+
 		delete(parentExpects, envVarKey)
 
 		if parentVal != valueExpected {
@@ -190,6 +229,113 @@ func (e *Environ) loadStrictOne(rawValues []store.RawValue, valueExpected string
 	return nil
 }
 
+// ReferencePrefix is the prefix of env var values which reference
+// configurations.
+const ReferencePrefix = "sicc://"
+
+// referenceVersionPrefix precedes the version in the reference fragment.
+const referenceVersionPrefix = "version="
+
+// ReferencePattern matches references to configurations in text, either
+// sicc:///path/to/key or ${sicc:/path/to/key}, optionally with the fragment
+// like #version=3. The first submatch of the first form, or the second of
+// the other, is the reference without ReferencePrefix. Trailing dots and
+// slashes are not part of the first form, so that references can end a
+// sentence.
+var ReferencePattern = regexp.MustCompile(`sicc://(/[\w.\-/]*[\w\-](?:#[\w=]+)?)|\$\{sicc:(/[\w.\-/]+(?:#[\w=]+)?)\}`)
+
+// LoadReferences replaces values of env vars in 'e' which reference
+// configurations, e.g. sicc:///prod/db/password or
+// sicc:///prod/db/password#version=3, with values from 's'. Names of replaced
+// env vars are returned.
+func (e *Environ) LoadReferences(ctx context.Context, s store.Store) ([]string, error) {
+	return e.loadReferences(ctx, s)
+}
+
+func (e *Environ) loadReferences(ctx context.Context, s store.Store) ([]string, error) {
+	envMap := e.Map()
+
+	keys := make([]string, 0)
+
+	for k, v := range envMap {
+		if strings.HasPrefix(v, ReferencePrefix) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name, version, err := ParseReference(envMap[k])
+		if err != nil {
+			return nil, InvalidReferenceError{Key: k, Reference: envMap[k], Err: err}
+		}
+
+		value, err := s.Get(ctx, name, version)
+		if errors.Is(err, store.ErrConfigNotFound) {
+			return nil, UnresolvedReferenceError{Key: k, Reference: envMap[k]}
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to fetch configuration referenced by %s: %w", k, err)
+		}
+
+		e.Set(k, *value.Value)
+	}
+
+	return keys, nil
+}
+
+// ParseReference parses the reference, like sicc:///prod/db/password#version=3,
+// into configuration name and version, which is -1 (latest) unless specified.
+func ParseReference(ref string) (store.ParameterName, int, error) {
+	configPath := strings.TrimPrefix(ref, ReferencePrefix)
+	version := -1
+
+	if i := strings.Index(configPath, "#"); i >= 0 {
+		fragment := configPath[i+1:]
+		configPath = configPath[:i]
+
+		if !strings.HasPrefix(fragment, referenceVersionPrefix) {
+			return store.ParameterName{}, 0, fmt.Errorf("unsupported fragment `%s`", fragment)
+		}
+
+		v, err := strconv.Atoi(strings.TrimPrefix(fragment, referenceVersionPrefix))
+		if err != nil || v < 1 {
+			return store.ParameterName{}, 0, fmt.Errorf("invalid version in fragment `%s`", fragment)
+		}
+
+		version = v
+	}
+
+	if !path.IsAbs(configPath) || strings.HasSuffix(configPath, "/") {
+		return store.ParameterName{}, 0, fmt.Errorf("configuration path `%s` is not absolute", configPath)
+	}
+
+	parameterPath, name := path.Split(configPath)
+
+	return store.ParameterName{ParameterPath: parameterPath, Name: name}, version, nil
+}
+
+type InvalidReferenceError struct {
+	// env-style key
+	Key       string
+	Reference string
+	Err       error
+}
+
+func (e InvalidReferenceError) Error() string {
+	return fmt.Sprintf("parent env has %s with invalid reference `%s`: %v", e.Key, e.Reference, e.Err)
+}
+
+type UnresolvedReferenceError struct {
+	// env-style key
+	Key       string
+	Reference string
+}
+
+func (e UnresolvedReferenceError) Error() string {
+	return fmt.Sprintf("parent env has %s referencing `%s`, but it was not in store", e.Key, e.Reference)
+}
+
 type StoreUnexpectedValueError struct {
 	// store-style key
 	Key           string
@@ -1,6 +1,7 @@
 package environ
 
 import (
+	"context"
 	"sort"
 	"testing"
 
@@ -184,11 +185,23 @@ func TestLoad(t *testing.T) {
 
 		var env Environ
 
-		err := env.Load(s, "", nil)
+		err := env.Load(context.Background(), s, NameMapper{}, "", nil, nil)
 
 		assert.Error(t, err)
 	})
 
+	t.Run("invalid mapped name without skipped", func(t *testing.T) {
+		s := store.NewMemoryStoreFromMap(map[string]string{"/db/password": "pass", "/db/user": "admin"})
+		mapper := NameMapper{Mappings: map[string]string{"db/password": "DB-PASSWORD"}}
+
+		var env Environ
+
+		err := env.Load(context.Background(), s, mapper, "/", nil, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"DB_USER": "admin"}, env.Map())
+	})
+
 	cases := []struct {
 		name               string
 		e                  Environ
@@ -237,7 +250,7 @@ func TestLoad(t *testing.T) {
 
 			collisions := make([]string, 0)
 
-			err := testCase.e.Load(s, testCase.prefixPath, &collisions)
+			err := testCase.e.Load(context.Background(), s, NameMapper{}, testCase.prefixPath, &collisions, nil)
 			if err != nil {
 				assert.EqualValues(t, testCase.expectedErr, err)
 			} else {
@@ -259,7 +272,7 @@ func TestLoadStrict(t *testing.T) {
 
 		var env Environ
 
-		err := env.LoadStrict(s, "", false, "")
+		err := env.LoadStrict(context.Background(), s, NameMapper{}, "", false, "")
 
 		assert.Error(t, err)
 	})
@@ -352,12 +365,83 @@ func TestLoadStrict(t *testing.T) {
 				strictVal = "changeme"
 			}
 
-			err := testCase.e.LoadStrict(s, strictVal, testCase.pristine, testCase.prefixPaths...)
+			err := testCase.e.LoadStrict(context.Background(), s, NameMapper{}, strictVal, testCase.pristine, testCase.prefixPaths...)
+			if err != nil {
+				assert.EqualValues(t, testCase.expectedErr, err)
+			} else {
+				assert.EqualValues(t, testCase.expectedEnvMap, testCase.e.Map())
+			}
+		})
+	}
+}
+
+//nolint:funlen
+func TestLoadReferences(t *testing.T) {
+	cases := []struct {
+		name               string
+		e                  Environ
+		expectedEnvMap     map[string]string
+		expectedReferences []string
+		expectedErr        error
+	}{
+		{
+			name: "basic",
+			e: fromMap(map[string]string{
+				"HOME":         "/tmp",
+				"DB_PASSWORD":  "sicc:///test/db/password",
+				"OLD_PASSWORD": "sicc:///test/db/password#version=1",
+			}),
+			expectedEnvMap: map[string]string{
+				"HOME":         "/tmp",
+				"DB_PASSWORD":  "pass2",
+				"OLD_PASSWORD": "pass1",
+			},
+			expectedReferences: []string{"DB_PASSWORD", "OLD_PASSWORD"},
+		},
+		{
+			name: "missing",
+			e: fromMap(map[string]string{
+				"DB_USERNAME": "sicc:///test/db/username",
+			}),
+			expectedErr: UnresolvedReferenceError{Key: "DB_USERNAME", Reference: "sicc:///test/db/username"},
+		},
+		{
+			name: "missing version",
+			e: fromMap(map[string]string{
+				"DB_PASSWORD": "sicc:///test/db/password#version=3",
+			}),
+			expectedErr: UnresolvedReferenceError{Key: "DB_PASSWORD", Reference: "sicc:///test/db/password#version=3"},
+		},
+	}
+
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			s := store.NewMemoryStore()
+
+			for _, v := range []string{"pass1", "pass2"} {
+				v := v
+				err := s.Put(context.Background(), store.ParameterName{ParameterPath: "/test/db/", Name: "password"}, store.Value{Value: &v})
+				assert.Nil(t, err)
+			}
+
+			references, err := testCase.e.LoadReferences(context.Background(), s)
 			if err != nil {
 				assert.EqualValues(t, testCase.expectedErr, err)
 			} else {
+				assert.Nil(t, testCase.expectedErr)
 				assert.EqualValues(t, testCase.expectedEnvMap, testCase.e.Map())
+				assert.EqualValues(t, testCase.expectedReferences, references)
 			}
 		})
 	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, ref := range []string{"sicc://test/db/password", "sicc:///test/db/", "sicc:///test/db/password#v=1", "sicc:///test/db/password#version=x"} {
+			env := fromMap(map[string]string{"DB_PASSWORD": ref})
+
+			_, err := env.LoadReferences(context.Background(), store.NewMemoryStore())
+			assert.IsType(t, InvalidReferenceError{}, err)
+		}
+	})
 }
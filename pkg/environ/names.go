@@ -0,0 +1,143 @@
+package environ
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// envVarNamePattern matches valid environment variable names, which can also
+// be used unquoted in shell code.
+var envVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidName reports whether the environment variable name consists of
+// letters, digits and underscores, not starting with a digit.
+func ValidName(name string) bool {
+	return envVarNamePattern.MatchString(name)
+}
+
+// InvalidNameError is returned when the environment variable name, generated
+// by the mapping, prefix or template, is not valid.
+type InvalidNameError struct {
+	// store-style key, relative to the prefix path
+	Key  string
+	Name string
+}
+
+func (e InvalidNameError) Error() string {
+	return fmt.Sprintf("invalid environment variable name %q of configuration %s", e.Name, e.Key)
+}
+
+// NameMapper maps configuration keys to environment variable names. The zero
+// value maps keys like configKeyToEnvVarName.
+type NameMapper struct {
+	// Mappings are environment variable names of configuration keys, which
+	// are relative to the prefix path, e.g. `db/password`
+	Mappings map[string]string
+
+	// Prefix is prepended to names which are not mapped explicitly
+	Prefix string
+
+	// KeepCase keeps case of the configuration key, instead of uppercasing
+	KeepCase bool
+
+	// Template generates names which are not mapped explicitly, see
+	// NewNameTemplate
+	Template *template.Template
+}
+
+// nameTemplateData is the data of the name template.
+type nameTemplateData struct {
+	// Key is the configuration key relative to the prefix path
+	Key string
+	// Name is the name derived from the key
+	Name string
+}
+
+// NewNameTemplate parses the template which generates environment variable
+// names. The configuration key relative to the prefix path is available as
+// .Key, and the name which would be derived from it as .Name. Functions
+// upper, lower and replace (e.g. `replace "/" "__"`) are available.
+func NewNameTemplate(text string) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"replace": func(old, new, s string) string {
+			return strings.ReplaceAll(s, old, new)
+		},
+	}
+
+	tmpl, err := template.New("name").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse name template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// EnvVarName maps the configuration key, relative to the prefix path, to the
+// environment variable name. Names generated by the mapping, prefix or
+// template which are not valid, see ValidName, are reported with
+// InvalidNameError. Names derived from the key alone are not checked.
+func (m NameMapper) EnvVarName(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+
+	name, custom, err := m.envVarName(key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkName(key, name, custom); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// envVarName maps the configuration key like EnvVarName, without checking the
+// name. It reports whether the name is generated by the mapping, prefix or
+// template.
+func (m NameMapper) envVarName(key string) (string, bool, error) {
+	if name, ok := m.Mappings[key]; ok {
+		return name, true, nil
+	}
+
+	name := configKeyToEnvVarName(key)
+	if m.KeepCase {
+		name = replaceEnvVarNameChars(key)
+	}
+
+	if m.Template != nil {
+		var buf bytes.Buffer
+
+		if err := m.Template.Execute(&buf, nameTemplateData{Key: key, Name: name}); err != nil {
+			return "", false, fmt.Errorf("failed to generate name of %s: %w", key, err)
+		}
+
+		name = buf.String()
+	}
+
+	return m.Prefix + name, m.Prefix != "" || m.Template != nil, nil
+}
+
+// checkName checks the environment variable name of the configuration key.
+func checkName(key, name string, custom bool) error {
+	if name == "" {
+		return errors.New("environment variable name of the configuration is empty")
+	}
+
+	if custom && !ValidName(name) {
+		return InvalidNameError{Key: key, Name: name}
+	}
+
+	return nil
+}
+
+// normalized reports whether all mapped names are normalized like
+// normalizeEnvVarName.
+func (m NameMapper) normalized() bool {
+	return len(m.Mappings) == 0 && !m.KeepCase && m.Template == nil && m.Prefix == strings.ToUpper(m.Prefix)
+}
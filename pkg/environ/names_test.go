@@ -0,0 +1,101 @@
+package environ
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/zbiljic/sicc/store"
+)
+
+func TestNameMapper(t *testing.T) {
+	tmpl, err := NewNameTemplate(`{{ .Key | replace "/" "__" | upper }}`)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		name     string
+		mapper   NameMapper
+		key      string
+		expected string
+	}{
+		{"default", NameMapper{}, "/db/password", "DB_PASSWORD"},
+		{"mapped", NameMapper{Mappings: map[string]string{"db/password": "DATABASE_PASSWORD"}, Prefix: "APP_"}, "/db/password", "DATABASE_PASSWORD"},
+		{"prefix", NameMapper{Prefix: "APP_"}, "/db/password", "APP_DB_PASSWORD"},
+		{"keep case", NameMapper{KeepCase: true}, "/db/Pass-word", "db_Pass_word"},
+		{"template", NameMapper{Template: tmpl, Prefix: "APP_"}, "/db/password", "APP_DB__PASSWORD"},
+	}
+
+	for _, testCase := range cases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			name, err := testCase.mapper.EnvVarName(testCase.key)
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.expected, name)
+		})
+	}
+
+	_, err = NewNameTemplate(`{{ .Key `)
+	assert.Error(t, err)
+
+	tmpl, err = NewNameTemplate(`{{ .Missing }}`)
+	assert.Nil(t, err)
+
+	_, err = NameMapper{Template: tmpl}.EnvVarName("db/password")
+	assert.Error(t, err)
+
+	tmpl, err = NewNameTemplate(`{{ .Key }}`)
+	assert.Nil(t, err)
+
+	name, err := NameMapper{}.EnvVarName("1password")
+	assert.Nil(t, err)
+	assert.Equal(t, "1PASSWORD", name)
+
+	invalid := []NameMapper{
+		{Template: tmpl},
+		{Mappings: map[string]string{"db/password;$(id)": "DB_PASSWORD;$(id)"}},
+		{Prefix: "1"},
+	}
+
+	for _, mapper := range invalid {
+		_, err = mapper.EnvVarName("db/password;$(id)")
+		assert.Error(t, err)
+	}
+
+	_, err = NameMapper{Template: tmpl}.EnvVarName("db/password")
+	assert.Equal(t, InvalidNameError{Key: "db/password", Name: "db/password"}, err)
+	assert.EqualError(t, err, `invalid environment variable name "db/password" of configuration db/password`)
+}
+
+func TestLoadMapped(t *testing.T) {
+	s := store.NewMemoryStoreFromMap(map[string]string{
+		"/test/db/username": "admin",
+		"/test/db/password": "pass",
+		"/test/db/host":     "localhost",
+		"/test/1password":   "other",
+	})
+
+	mapper := NameMapper{Mappings: map[string]string{"db/password": "PGPASSWORD", "db/host": "DB HOST"}, Prefix: "APP_"}
+
+	var (
+		env     Environ
+		skipped []InvalidNameError
+	)
+
+	err := env.Load(context.Background(), s, mapper, "/test", &[]string{}, &skipped)
+	assert.Nil(t, err)
+	assert.EqualValues(t, map[string]string{"APP_DB_USERNAME": "admin", "PGPASSWORD": "pass", "APP_1PASSWORD": "other"}, env.Map())
+	assert.Equal(t, []InvalidNameError{{Key: "db/host", Name: "DB HOST"}}, skipped)
+
+	env = nil
+
+	err = env.Load(context.Background(), s, NameMapper{}, "/test", &[]string{}, &skipped)
+	assert.Nil(t, err)
+	assert.Equal(t, "other", env.Map()["1PASSWORD"])
+
+	env = fromMap(map[string]string{"PGPASSWORD": "changeme", "db_username": "changeme"})
+
+	err = env.LoadStrict(context.Background(), s, NameMapper{KeepCase: true, Mappings: mapper.Mappings}, "changeme", false, "/test")
+	assert.Nil(t, err)
+	assert.EqualValues(t, map[string]string{"db_username": "admin", "PGPASSWORD": "pass"}, env.Map())
+}
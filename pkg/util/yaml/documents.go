@@ -0,0 +1,48 @@
+package yaml
+
+import (
+	"bytes"
+	"unicode"
+)
+
+// Document is a single document of a YAML stream.
+type Document struct {
+	// Line is the line number in the stream where the document starts.
+	Line int
+	Data []byte
+}
+
+// SplitDocuments splits YAML stream into documents separated by `---` lines,
+// keeping track of lines where the documents start, so that errors can be
+// reported relative to the stream. Documents containing only whitespace are
+// skipped.
+func SplitDocuments(data []byte) []Document {
+	documents := []Document{}
+
+	current := Document{Line: 1}
+
+	appendCurrent := func() {
+		if len(bytes.TrimSpace(current.Data)) != 0 {
+			documents = append(documents, current)
+		}
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	for i, line := range lines {
+		if bytes.HasPrefix(line, []byte(separator)) &&
+			len(bytes.TrimRightFunc(line[len(separator):], unicode.IsSpace)) == 0 {
+			appendCurrent()
+
+			current = Document{Line: i + 2} //nolint:gomnd
+
+			continue
+		}
+
+		current.Data = append(current.Data, line...)
+	}
+
+	appendCurrent()
+
+	return documents
+}
@@ -0,0 +1,31 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitDocuments(t *testing.T) {
+	data := []byte(`---
+path: dev
+a: 1
+---
+
+path: prod
+---   
+--- 
+b: 2`)
+
+	documents := SplitDocuments(data)
+
+	assert.Equal(t, []Document{
+		{Line: 2, Data: []byte("path: dev\na: 1\n")},
+		{Line: 5, Data: []byte("\npath: prod\n")},
+		{Line: 9, Data: []byte("b: 2")},
+	}, documents)
+
+	assert.Empty(t, SplitDocuments([]byte("\n---\n")))
+
+	assert.Equal(t, []Document{{Line: 1, Data: []byte("a: 1\n")}}, SplitDocuments([]byte("a: 1\n")))
+}
@@ -1,20 +1,35 @@
 package store
 
 import (
+	"errors"
 	"os"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
 )
 
 const (
-	regionEnvVar            = "SICC_AWS_REGION"
-	customSSMEndpointEnvVar = "SICC_AWS_SSM_ENDPOINT"
+	regionEnvVar                       = "SICC_AWS_REGION"
+	customSSMEndpointEnvVar            = "SICC_AWS_SSM_ENDPOINT"
+	customSecretsManagerEndpointEnvVar = "SICC_AWS_SECRETSMANAGER_ENDPOINT"
 )
 
-func getSession(numRetries int) (*session.Session, *string, error) {
+// customEndpointEnvVars maps service to the environment variable used to
+// override its endpoint
+var customEndpointEnvVars = map[string]string{
+	ssm.EndpointsID:            customSSMEndpointEnvVar,
+	secretsmanager.EndpointsID: customSecretsManagerEndpointEnvVar,
+}
+
+// getSession creates AWS session, using the named profile from shared
+// configuration if one is given.
+func getSession(numRetries int, profile string) (*session.Session, *string, error) {
 	var region *string
 
 	if regionOverride, ok := os.LookupEnv(regionEnvVar); ok {
@@ -23,10 +38,9 @@ func getSession(numRetries int) (*session.Session, *string, error) {
 
 	//nolint:lll
 	endpointResolver := func(service, region string, optFns ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
-		customSsmEndpoint, ok := os.LookupEnv(customSSMEndpointEnvVar)
-		if ok {
+		if customEndpoint, ok := os.LookupEnv(customEndpointEnvVars[service]); ok {
 			return endpoints.ResolvedEndpoint{
-				URL: customSsmEndpoint,
+				URL: customEndpoint,
 			}, nil
 		}
 
@@ -40,6 +54,7 @@ func getSession(numRetries int) (*session.Session, *string, error) {
 				MaxRetries:       aws.Int(numRetries),
 				EndpointResolver: endpoints.ResolverFunc(endpointResolver),
 			},
+			Profile:           profile,
 			SharedConfigState: session.SharedConfigEnable,
 		},
 	)
@@ -65,6 +80,18 @@ func getSession(numRetries int) (*session.Session, *string, error) {
 	return retSession, region, nil
 }
 
+// isAWSThrottlingError reports whether AWS rejected the request because of
+// request rate.
+func isAWSThrottlingError(err error) bool {
+	var aerr awserr.Error
+
+	if !errors.As(err, &aerr) {
+		return false
+	}
+
+	return request.IsErrorThrottle(aerr) || aerr.Code() == ssm.ErrCodeTooManyUpdates
+}
+
 func stringsToAWSStrings(slice []string) []*string {
 	ret := []*string{}
 	for _, s := range slice {
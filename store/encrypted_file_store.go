@@ -0,0 +1,475 @@
+package store
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// encryptedFileFormatVersion is the version of the encrypted file envelope
+	encryptedFileFormatVersion = 1
+
+	// sealedValuePrefix marks values which are additionally sealed inside the
+	// encrypted file, so they stay encrypted after the file is decrypted
+	sealedValuePrefix = "sicc:sealed:"
+
+	// scrypt parameters recommended for interactive logins
+	scryptN       = 32768
+	scryptR       = 8
+	scryptP       = 1
+	scryptKeyLen  = 32
+	scryptSaltLen = 16
+
+	// HKDF info strings separating keys derived from the scrypt output
+	fileKeyInfo  = "sicc encrypted file"
+	valueKeyInfo = "sicc sealed value"
+)
+
+// ErrDecryptionFailed is returned when the encrypted file can not be
+// decrypted, because of wrong passphrase or key file, or tampered content.
+var ErrDecryptionFailed = errors.New("failed to decrypt, wrong passphrase or key file")
+
+// EncryptedFileStore implements the Store interface for storing
+// configurations in a single local file encrypted with AES-256-GCM. The key
+// is derived from a passphrase (or contents of a key file) using scrypt.
+//
+// Values of secure configurations are sealed once more on their own, with a
+// separate key, so they are not revealed when the rest of the file is
+// decrypted in memory.
+type EncryptedFileStore struct {
+	file  *FileStore
+	codec *encryptedCodec
+}
+
+// encryptedFile is the envelope written to disk.
+type encryptedFile struct {
+	Version int       `json:"version"`
+	KDF     kdfParams `json:"kdf"`
+	Nonce   []byte    `json:"nonce"`
+	Data    []byte    `json:"data"`
+}
+
+type kdfParams struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// encryptedCodec encrypts the whole file contents. Separate keys for the
+// file and for sealed values are derived from the scrypt output with HKDF.
+// The derived keys are kept for as long as KDF parameters of the file are
+// the same.
+type encryptedCodec struct {
+	secret []byte
+
+	mu       sync.Mutex
+	kdf      kdfParams
+	fileKey  []byte
+	valueKey []byte
+}
+
+// NewEncryptedFileStore creates a new EncryptedFileStore. The secret is
+// either a passphrase or contents of a key file.
+func NewEncryptedFileStore(path string, secret []byte) (*EncryptedFileStore, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("passphrase or key file must be specified for encrypted file store")
+	}
+
+	file, err := NewFileStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := &encryptedCodec{
+		secret: secret,
+	}
+
+	file.codec = codec
+
+	return &EncryptedFileStore{
+		file:  file,
+		codec: codec,
+	}, nil
+}
+
+func (s *EncryptedFileStore) Put(ctx context.Context, name ParameterName, value Value) error {
+	if value.Value == nil {
+		return ErrValueMissing
+	}
+
+	return s.file.update(ctx, func(m *MemoryStore) error {
+		if !value.Meta.Secure {
+			return m.Put(ctx, name, value)
+		}
+
+		aead, err := s.codec.valueAEAD()
+		if err != nil {
+			return err
+		}
+
+		sealed, err := sealValue(aead, memoryStoreKey(name), *value.Value)
+		if err != nil {
+			return err
+		}
+
+		value.Value = &sealed
+
+		return m.Put(ctx, name, value)
+	})
+}
+
+func (s *EncryptedFileStore) Get(ctx context.Context, name ParameterName, version int) (Value, error) {
+	var result Value
+
+	err := s.file.view(ctx, func(m *MemoryStore) error {
+		value, err := m.Get(ctx, name, version)
+		if err != nil {
+			return err
+		}
+
+		result, err = s.open(value)
+
+		return err
+	})
+
+	return result, err
+}
+
+func (s *EncryptedFileStore) List(ctx context.Context, prefix string, includeValues bool) ([]Value, error) {
+	var result []Value
+
+	err := s.file.view(ctx, func(m *MemoryStore) error {
+		values, err := m.List(ctx, prefix, includeValues)
+		if err != nil {
+			return err
+		}
+
+		for i := range values {
+			if values[i], err = s.open(values[i]); err != nil {
+				return err
+			}
+		}
+
+		result = values
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *EncryptedFileStore) ListRaw(ctx context.Context, prefix string) ([]RawValue, error) {
+	var result []RawValue
+
+	err := s.file.view(ctx, func(m *MemoryStore) error {
+		values, err := m.List(ctx, prefix, true)
+		if err != nil {
+			return err
+		}
+
+		result = make([]RawValue, 0, len(values))
+
+		for _, value := range values {
+			value, err := s.open(value)
+			if err != nil {
+				return err
+			}
+
+			result = append(result, RawValue{
+				Value: *value.Value,
+				Key:   value.Meta.Key,
+			})
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+func (s *EncryptedFileStore) Delete(ctx context.Context, name ParameterName) error {
+	return s.file.update(ctx, func(m *MemoryStore) error {
+		return m.Delete(ctx, name)
+	})
+}
+
+func (s *EncryptedFileStore) History(ctx context.Context, name ParameterName) ([]Value, error) {
+	var result []Value
+
+	err := s.file.view(ctx, func(m *MemoryStore) error {
+		values, err := m.History(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		for i := range values {
+			if values[i], err = s.open(values[i]); err != nil {
+				return err
+			}
+		}
+
+		result = values
+
+		return nil
+	})
+
+	return result, err
+}
+
+// open unseals the value of a secure configuration.
+func (s *EncryptedFileStore) open(value Value) (Value, error) {
+	if value.Value == nil || !value.Meta.Secure {
+		return value, nil
+	}
+
+	aead, err := s.codec.valueAEAD()
+	if err != nil {
+		return Value{}, err
+	}
+
+	v, err := openValue(aead, value.Meta.Key, *value.Value)
+	if err != nil {
+		return Value{}, fmt.Errorf("failed to open value of `%s`: %w", value.Meta.Key, err)
+	}
+
+	value.Value = &v
+
+	return value, nil
+}
+
+func (c *encryptedCodec) encode(entries map[string]fileEntry) ([]byte, error) {
+	kdf, fileKey, _, err := c.ensureKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	ad, err := json.Marshal(kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(encryptedFile{
+		Version: encryptedFileFormatVersion,
+		KDF:     kdf,
+		Nonce:   nonce,
+		Data:    aead.Seal(nil, nonce, plaintext, ad),
+	}, "", "  ")
+}
+
+func (c *encryptedCodec) decode(data []byte) (map[string]fileEntry, error) {
+	var f encryptedFile
+
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	if f.Version != encryptedFileFormatVersion {
+		return nil, fmt.Errorf("unsupported encrypted file version %d", f.Version)
+	}
+
+	if f.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation function `%s`", f.KDF.Name)
+	}
+
+	fileKey, _, err := c.deriveKeys(f.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, ErrDecryptionFailed
+	}
+
+	ad, err := json.Marshal(f.KDF)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, f.Nonce, f.Data, ad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	entries := map[string]fileEntry{}
+
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ensureKeys returns keys derived with a new random salt, unless keys were
+// already derived from an existing file.
+func (c *encryptedCodec) ensureKeys() (kdfParams, []byte, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fileKey != nil {
+		return c.kdf, c.fileKey, c.valueKey, nil
+	}
+
+	salt := make([]byte, scryptSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return kdfParams{}, nil, nil, err
+	}
+
+	kdf := kdfParams{
+		Name: "scrypt",
+		Salt: salt,
+		N:    scryptN,
+		R:    scryptR,
+		P:    scryptP,
+	}
+
+	if err := c.deriveKeysLocked(kdf); err != nil {
+		return kdfParams{}, nil, nil, err
+	}
+
+	return c.kdf, c.fileKey, c.valueKey, nil
+}
+
+// deriveKeys returns the file and value keys for the KDF parameters of the
+// file, deriving them only when the parameters changed.
+func (c *encryptedCodec) deriveKeys(kdf kdfParams) ([]byte, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.deriveKeysLocked(kdf); err != nil {
+		return nil, nil, err
+	}
+
+	return c.fileKey, c.valueKey, nil
+}
+
+func (c *encryptedCodec) deriveKeysLocked(kdf kdfParams) error {
+	if c.fileKey != nil && string(c.kdf.Salt) == string(kdf.Salt) &&
+		c.kdf.N == kdf.N && c.kdf.R == kdf.R && c.kdf.P == kdf.P {
+		return nil
+	}
+
+	master, err := scrypt.Key(c.secret, kdf.Salt, kdf.N, kdf.R, kdf.P, scryptKeyLen)
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	fileKey, err := expandKey(master, fileKeyInfo)
+	if err != nil {
+		return err
+	}
+
+	valueKey, err := expandKey(master, valueKeyInfo)
+	if err != nil {
+		return err
+	}
+
+	c.kdf = kdf
+	c.fileKey = fileKey
+	c.valueKey = valueKey
+
+	return nil
+}
+
+// expandKey derives a subkey for the given purpose from the scrypt output.
+func expandKey(master []byte, info string) ([]byte, error) {
+	key := make([]byte, scryptKeyLen)
+
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, master, []byte(info)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	return key, nil
+}
+
+// valueAEAD returns the cipher used for sealing values of secure
+// configurations, keyed separately from the file.
+func (c *encryptedCodec) valueAEAD() (cipher.AEAD, error) {
+	_, _, valueKey, err := c.ensureKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return newAEAD(valueKey)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// sealValue encrypts the value, binding it to the configuration key so it
+// can not be moved to another configuration.
+func sealValue(aead cipher.AEAD, key, value string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(key))
+
+	return sealedValuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openValue(aead cipher.AEAD, key, value string) (string, error) {
+	if !strings.HasPrefix(value, sealedValuePrefix) {
+		return "", errors.New("value is not sealed")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, sealedValuePrefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrDecryptionFailed
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return "", ErrDecryptionFailed
+	}
+
+	return string(plaintext), nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ Store = &EncryptedFileStore{}
+)
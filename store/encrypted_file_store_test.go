@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptedFileStore(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "sicc")
+	assert.Nil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "store.enc")
+
+	s, err := NewEncryptedFileStore(filename, []byte("passphrase"))
+	assert.Nil(t, err)
+
+	username := "admin"
+	password := "very-secret-password"
+
+	err = s.Put(ctx, ParameterName{ParameterPath: "/test/db", Name: "password"}, Value{Meta: Metadata{Secure: true}})
+	assert.Equal(t, ErrValueMissing, err)
+
+	err = s.Put(ctx, ParameterName{ParameterPath: "/test/db", Name: "username"}, Value{Value: &username})
+	assert.Nil(t, err)
+
+	err = s.Put(ctx, ParameterName{ParameterPath: "/test/db", Name: "password"}, Value{Value: &password, Meta: Metadata{Secure: true}})
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(data), "admin"))
+	assert.False(t, strings.Contains(string(data), "username"))
+
+	// reopen with the same passphrase
+	s, err = NewEncryptedFileStore(filename, []byte("passphrase"))
+	assert.Nil(t, err)
+
+	config, err := s.Get(ctx, ParameterName{ParameterPath: "/test/db", Name: "password"}, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, password, *config.Value)
+	assert.True(t, config.Meta.Secure)
+	assert.Equal(t, 1, config.Meta.Version)
+
+	rawValues, err := s.ListRaw(ctx, "/test")
+	assert.Nil(t, err)
+	assert.Equal(t, []RawValue{
+		{Value: password, Key: "/test/db/password"},
+		{Value: username, Key: "/test/db/username"},
+	}, rawValues)
+
+	// secure values stay sealed after the file is decrypted
+	var sealed string
+
+	err = s.file.view(ctx, func(m *MemoryStore) error {
+		sealed = *m.m["/test/db/password"][0].Value
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(sealed, sealedValuePrefix))
+
+	// wrong passphrase
+	s, err = NewEncryptedFileStore(filename, []byte("wrong"))
+	assert.Nil(t, err)
+
+	_, err = s.ListRaw(ctx, "/test")
+	assert.True(t, strings.Contains(err.Error(), ErrDecryptionFailed.Error()))
+}
+
+func TestEncryptedFileStoreConcurrentGet(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "sicc")
+	assert.Nil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "store.enc")
+
+	s, err := NewEncryptedFileStore(filename, []byte("passphrase"))
+	assert.Nil(t, err)
+
+	password := "very-secret-password"
+	name := ParameterName{ParameterPath: "/test/db", Name: "password"}
+
+	err = s.Put(ctx, name, Value{Value: &password, Meta: Metadata{Secure: true}})
+	assert.Nil(t, err)
+
+	// a new store derives keys while reading in parallel
+	s, err = NewEncryptedFileStore(filename, []byte("passphrase"))
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			config, err := s.Get(ctx, name, -1)
+			assert.Nil(t, err)
+			assert.Equal(t, password, *config.Value)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.NotEqual(t, s.codec.fileKey, s.codec.valueKey)
+}
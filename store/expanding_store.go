@@ -0,0 +1,339 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// expansionPattern matches references to other configurations in values,
+// like ${/prod/db/host}. References preceded by another `$` are escaped.
+var expansionPattern = regexp.MustCompile(`\$?\$\{(/[\w.\-/]+)\}`)
+
+// ExpandingStore implements the Store interface wrapping another store, with
+// references to other configurations in values, like ${/prod/db/host},
+// replaced by their values on read. `$${/prod/db/host}` is read as
+// `${/prod/db/host}`. Values are written and their history read unchanged.
+type ExpandingStore struct {
+	store Store
+}
+
+// ExpansionCycleError is returned when configurations reference each other.
+type ExpansionCycleError struct {
+	Keys []string
+}
+
+func (e ExpansionCycleError) Error() string {
+	return fmt.Sprintf("cyclic reference between configurations: %s", strings.Join(e.Keys, " -> "))
+}
+
+// ExpansionMissingError is returned when referenced configuration does not
+// exist.
+type ExpansionMissingError struct {
+	Key       string
+	Reference string
+}
+
+func (e ExpansionMissingError) Error() string {
+	return fmt.Sprintf("configuration `%s` referenced by `%s` not found (write `$${%s}` for literal text)",
+		e.Reference, e.Key, e.Reference)
+}
+
+// ExpansionMissingErrors is returned when multiple referenced configurations
+// do not exist.
+type ExpansionMissingErrors struct {
+	Errors []ExpansionMissingError
+}
+
+func (e ExpansionMissingErrors) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d referenced configurations not found:", len(e.Errors))
+
+	for _, err := range e.Errors {
+		fmt.Fprintf(&b, "\n  `%s` referenced by `%s`", err.Reference, err.Key)
+	}
+
+	b.WriteString("\nreferences written like `$${/path/to/key}` are kept as literal text")
+
+	return b.String()
+}
+
+// NewExpandingStore creates a new ExpandingStore.
+func NewExpandingStore(s Store) *ExpandingStore {
+	return &ExpandingStore{store: s}
+}
+
+// Put writes the value unchanged.
+func (s *ExpandingStore) Put(ctx context.Context, name ParameterName, value Value) error {
+	return s.store.Put(ctx, name, value)
+}
+
+// Get retrieves the configuration with references expanded. The version
+// applies only to the configuration, references are expanded to latest
+// values.
+func (s *ExpandingStore) Get(ctx context.Context, name ParameterName, version int) (Value, error) {
+	value, err := s.store.Get(ctx, name, version)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return s.expandValue(ctx, name, value)
+}
+
+// GetStage retrieves the configuration version labeled with the stage, with
+// references expanded to latest values.
+func (s *ExpandingStore) GetStage(ctx context.Context, name ParameterName, stage string) (Value, error) {
+	stageStore, ok := s.store.(StageStore)
+	if !ok {
+		return Value{}, ErrStagesNotSupported
+	}
+
+	value, err := stageStore.GetStage(ctx, name, stage)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return s.expandValue(ctx, name, value)
+}
+
+// expandValue expands references in the value of the configuration.
+func (s *ExpandingStore) expandValue(ctx context.Context, name ParameterName, value Value) (Value, error) {
+	key := path.Join(name.ParameterPath, name.Name)
+	e := s.newExpander(ctx, map[string]string{})
+
+	expanded, err := e.expandValue(*value.Value, []string{key})
+	if err != nil {
+		return Value{}, e.error(err)
+	}
+
+	value.Value = &expanded
+
+	return value, nil
+}
+
+// List lists configurations with references in their values expanded.
+func (s *ExpandingStore) List(ctx context.Context, prefix string, includeValues bool) ([]Value, error) {
+	values, err := s.store.List(ctx, prefix, includeValues)
+	if err != nil || !includeValues {
+		return values, err
+	}
+
+	raw := make(map[string]string, len(values))
+
+	for _, value := range values {
+		raw[value.Meta.Key] = *value.Value
+	}
+
+	e := s.newExpander(ctx, raw)
+
+	for i := range values {
+		expanded, err := e.expand(values[i].Meta.Key, nil)
+		if err != nil && !isExpansionMissing(err) {
+			return nil, err
+		}
+
+		values[i].Value = &expanded
+	}
+
+	if len(e.missing) > 0 {
+		return nil, e.error(nil)
+	}
+
+	return values, nil
+}
+
+// ListRaw lists raw configurations with references expanded.
+func (s *ExpandingStore) ListRaw(ctx context.Context, prefix string) ([]RawValue, error) {
+	values, err := s.store.ListRaw(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]string, len(values))
+
+	for _, value := range values {
+		raw[value.Key] = value.Value
+	}
+
+	e := s.newExpander(ctx, raw)
+
+	for i := range values {
+		expanded, err := e.expand(values[i].Key, nil)
+		if err != nil && !isExpansionMissing(err) {
+			return nil, err
+		}
+
+		values[i].Value = expanded
+	}
+
+	if len(e.missing) > 0 {
+		return nil, e.error(nil)
+	}
+
+	return values, nil
+}
+
+// Delete removes the configuration.
+func (s *ExpandingStore) Delete(ctx context.Context, name ParameterName) error {
+	return s.store.Delete(ctx, name)
+}
+
+// History returns all versions of the configuration unchanged.
+func (s *ExpandingStore) History(ctx context.Context, name ParameterName) ([]Value, error) {
+	return s.store.History(ctx, name)
+}
+
+// KeepsSecure reports whether the wrapped store keeps the secure flag.
+func (s *ExpandingStore) KeepsSecure() bool {
+	return KeepsSecure(s.store)
+}
+
+// KeepsDescription reports whether the wrapped store keeps the description.
+func (s *ExpandingStore) KeepsDescription() bool {
+	return KeepsDescription(s.store)
+}
+
+// expander expands values, fetching referenced configurations which are not
+// already known only once. Missing references are collected, so that all of
+// them are reported together.
+type expander struct {
+	ctx      context.Context
+	store    Store
+	raw      map[string]string
+	expanded map[string]string
+	missing  []ExpansionMissingError
+}
+
+func (s *ExpandingStore) newExpander(ctx context.Context, raw map[string]string) *expander {
+	return &expander{
+		ctx:      ctx,
+		store:    s.store,
+		raw:      raw,
+		expanded: map[string]string{},
+	}
+}
+
+// expand returns the expanded value of the configuration, which is
+// referenced through the chain of keys.
+func (e *expander) expand(key string, chain []string) (string, error) {
+	if value, ok := e.expanded[key]; ok {
+		return value, nil
+	}
+
+	for i, k := range chain {
+		if k == key {
+			return "", ExpansionCycleError{Keys: append(append([]string{}, chain[i:]...), key)}
+		}
+	}
+
+	chain = append(chain, key)
+
+	raw, ok := e.raw[key]
+	if !ok {
+		parameterPath, name := path.Split(key)
+
+		value, err := e.store.Get(e.ctx, ParameterName{ParameterPath: parameterPath, Name: name}, -1)
+		if errors.Is(err, ErrConfigNotFound) && len(chain) > 1 {
+			return "", e.addMissing(ExpansionMissingError{Key: chain[len(chain)-2], Reference: key})
+		} else if err != nil {
+			return "", err
+		}
+
+		raw = *value.Value
+	}
+
+	expanded, err := e.expandValue(raw, chain)
+	if err != nil {
+		return "", err
+	}
+
+	e.expanded[key] = expanded
+
+	return expanded, nil
+}
+
+// expandValue replaces references in the value of the configuration. Other
+// references are still expanded after a missing one, so that all missing
+// references are collected.
+func (e *expander) expandValue(value string, chain []string) (string, error) {
+	var err, missing error
+
+	expanded := expansionPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if err != nil {
+			return match
+		}
+
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		ref, refErr := e.expand(expansionPattern.FindStringSubmatch(match)[1], chain)
+		if isExpansionMissing(refErr) {
+			if missing == nil {
+				missing = refErr
+			}
+
+			return match
+		}
+
+		err = refErr
+
+		return ref
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if missing != nil {
+		return "", missing
+	}
+
+	return expanded, nil
+}
+
+// addMissing collects the missing reference, and returns it.
+func (e *expander) addMissing(err ExpansionMissingError) error {
+	for _, m := range e.missing {
+		if m == err {
+			return err
+		}
+	}
+
+	e.missing = append(e.missing, err)
+
+	return err
+}
+
+// error returns the error reporting all missing references, or err when there
+// are none, or it is not caused by a missing reference.
+func (e *expander) error(err error) error {
+	if err != nil && !isExpansionMissing(err) {
+		return err
+	}
+
+	switch len(e.missing) {
+	case 0:
+		return err
+	case 1:
+		return e.missing[0]
+	default:
+		return ExpansionMissingErrors{Errors: e.missing}
+	}
+}
+
+func isExpansionMissing(err error) bool {
+	var missing ExpansionMissingError
+	return errors.As(err, &missing)
+}
+
+// Check the interfaces are satisfied
+var (
+	_ Store          = &ExpandingStore{}
+	_ MetadataKeeper = &ExpandingStore{}
+	_ StageStore     = &ExpandingStore{}
+)
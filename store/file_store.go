@@ -0,0 +1,382 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cast"
+)
+
+// FileStore implements the Store interface for storing configurations in a
+// local file. The file is read into a MemoryStore for every operation, and
+// written back atomically when it was changed. Files with `.yaml` or `.yml`
+// extension are stored as YAML, all others as JSON.
+type FileStore struct {
+	path  string
+	codec fileCodec
+
+	// mu guards the file within the process, in addition to the file lock
+	// which guards it across processes
+	mu sync.RWMutex
+}
+
+// fileCodec converts configurations to and from the file contents.
+type fileCodec interface {
+	encode(entries map[string]fileEntry) ([]byte, error)
+	decode(data []byte) (map[string]fileEntry, error)
+}
+
+// plainCodec stores configurations as plain JSON or YAML.
+type plainCodec struct {
+	yaml bool
+}
+
+// fileEntry represents a single configuration in the file. In the file it is
+// either an object, or just a scalar value (useful for hand written fixtures),
+// which is converted to string like in import.
+type fileEntry struct {
+	Value            string    `json:"value"`
+	Description      string    `json:"description,omitempty"`
+	Secure           bool      `json:"secure,omitempty"`
+	KeyID            string    `json:"keyId,omitempty"`
+	Version          int       `json:"version,omitempty"`
+	LastModifiedDate time.Time `json:"lastModifiedDate"`
+	LastModifiedUser string    `json:"lastModifiedUser,omitempty"`
+	// History holds previous versions, oldest first.
+	History []fileEntry `json:"history,omitempty"`
+}
+
+func (e *fileEntry) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`{`)) {
+		type entry fileEntry
+
+		return json.Unmarshal(data, (*entry)(e))
+	}
+
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		e.Value = v.String()
+	case string, bool, nil:
+		e.Value = cast.ToString(v)
+	default:
+		return fmt.Errorf("unsupported configuration value: %s", data)
+	}
+
+	return nil
+}
+
+// NewFileStore creates a new FileStore
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("file path must be specified for file store")
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+
+	return &FileStore{
+		path:  path,
+		codec: plainCodec{yaml: ext == ".yaml" || ext == ".yml"},
+	}, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, name ParameterName, value Value) error {
+	if value.Value == nil {
+		return ErrValueMissing
+	}
+
+	return s.update(ctx, func(m *MemoryStore) error {
+		return m.Put(ctx, name, value)
+	})
+}
+
+func (s *FileStore) Get(ctx context.Context, name ParameterName, version int) (Value, error) {
+	var result Value
+
+	err := s.view(ctx, func(m *MemoryStore) error {
+		var err error
+		result, err = m.Get(ctx, name, version)
+
+		return err
+	})
+
+	return result, err
+}
+
+func (s *FileStore) List(ctx context.Context, prefix string, includeValues bool) ([]Value, error) {
+	var result []Value
+
+	err := s.view(ctx, func(m *MemoryStore) error {
+		var err error
+		result, err = m.List(ctx, prefix, includeValues)
+
+		return err
+	})
+
+	return result, err
+}
+
+func (s *FileStore) ListRaw(ctx context.Context, prefix string) ([]RawValue, error) {
+	var result []RawValue
+
+	err := s.view(ctx, func(m *MemoryStore) error {
+		var err error
+		result, err = m.ListRaw(ctx, prefix)
+
+		return err
+	})
+
+	return result, err
+}
+
+func (s *FileStore) Delete(ctx context.Context, name ParameterName) error {
+	return s.update(ctx, func(m *MemoryStore) error {
+		return m.Delete(ctx, name)
+	})
+}
+
+func (s *FileStore) History(ctx context.Context, name ParameterName) ([]Value, error) {
+	var result []Value
+
+	err := s.view(ctx, func(m *MemoryStore) error {
+		var err error
+		result, err = m.History(ctx, name)
+
+		return err
+	})
+
+	return result, err
+}
+
+// view runs fn against the file contents while holding a shared lock.
+func (s *FileStore) view(ctx context.Context, fn func(m *MemoryStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	unlock, err := lockFile(s.lockPath(), false)
+	if err != nil {
+		return fmt.Errorf("failed to lock file store: %w", err)
+	}
+	defer unlock() //nolint:errcheck
+
+	m, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+// update runs fn against the file contents while holding an exclusive lock,
+// and writes the changes back if fn succeeds.
+func (s *FileStore) update(ctx context.Context, fn func(m *MemoryStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockFile(s.lockPath(), true)
+	if err != nil {
+		return fmt.Errorf("failed to lock file store: %w", err)
+	}
+	defer unlock() //nolint:errcheck
+
+	m, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(m); err != nil {
+		return err
+	}
+
+	return s.save(m)
+}
+
+func (s *FileStore) lockPath() string {
+	return s.path + ".lock"
+}
+
+func (s *FileStore) load() (*MemoryStore, error) {
+	m := NewMemoryStore()
+
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+
+		return nil, fmt.Errorf("failed to read file store: %w", err)
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return m, nil
+	}
+
+	entries, err := s.codec.decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode file store (%s): %w", s.path, err)
+	}
+
+	for k, e := range entries {
+		key := memoryStoreKey(ParameterName{Name: k})
+
+		for _, h := range e.History {
+			m.m[key] = append(m.m[key], h.toValue(key))
+		}
+
+		m.m[key] = append(m.m[key], e.toValue(key))
+	}
+
+	return m, nil
+}
+
+func (s *FileStore) save(m *MemoryStore) error {
+	entries := map[string]fileEntry{}
+
+	for k, history := range m.m {
+		if len(history) == 0 {
+			continue
+		}
+
+		entry := newFileEntry(history[len(history)-1])
+
+		for _, v := range history[:len(history)-1] {
+			entry.History = append(entry.History, newFileEntry(v))
+		}
+
+		entries[k] = entry
+	}
+
+	data, err := s.codec.encode(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode file store: %w", err)
+	}
+
+	return writeFileAtomic(s.path, data, 0600) //nolint:gomnd
+}
+
+func newFileEntry(v Value) fileEntry {
+	return fileEntry{
+		Value:            *v.Value,
+		Description:      v.Meta.Description,
+		Secure:           v.Meta.Secure,
+		KeyID:            v.Meta.KeyID,
+		Version:          v.Meta.Version,
+		LastModifiedDate: v.Meta.LastModifiedDate,
+		LastModifiedUser: v.Meta.LastModifiedUser,
+	}
+}
+
+func (e fileEntry) toValue(key string) Value {
+	v := e.Value
+
+	version := e.Version
+	if version == 0 {
+		version = 1
+	}
+
+	return Value{
+		Value: &v,
+		Meta: Metadata{
+			Key:              key,
+			Description:      e.Description,
+			Secure:           e.Secure,
+			KeyID:            e.KeyID,
+			Version:          version,
+			LastModifiedDate: e.LastModifiedDate,
+			LastModifiedUser: e.LastModifiedUser,
+		},
+	}
+}
+
+func (c plainCodec) encode(entries map[string]fileEntry) ([]byte, error) {
+	if c.yaml {
+		return yaml.Marshal(entries)
+	}
+
+	return json.MarshalIndent(entries, "", "  ")
+}
+
+func (c plainCodec) decode(data []byte) (map[string]fileEntry, error) {
+	entries := map[string]fileEntry{}
+
+	var err error
+
+	if c.yaml {
+		err = yaml.Unmarshal(data, &entries)
+	} else {
+		err = json.Unmarshal(data, &entries)
+	}
+
+	return entries, err
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it over the target, so readers never observe partial content.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+base+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	// cleanup in case of failure; no-op after successful rename
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	return nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ Store = &FileStore{}
+)
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+
+	for _, filename := range []string{"store.json", "store.yaml"} {
+		filename := filename
+		t.Run(filename, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sicc")
+			assert.Nil(t, err)
+
+			defer os.RemoveAll(dir)
+
+			s, err := NewFileStore(filepath.Join(dir, filename))
+			assert.Nil(t, err)
+
+			name := ParameterName{ParameterPath: "/test/db", Name: "password"}
+			value := "pass"
+
+			_, err = s.Get(ctx, name, -1)
+			assert.Equal(t, ErrConfigNotFound, err)
+
+			err = s.Put(ctx, name, Value{Meta: Metadata{Secure: true}})
+			assert.Equal(t, ErrValueMissing, err)
+
+			err = s.Put(ctx, name, Value{Value: &value, Meta: Metadata{Secure: true}})
+			assert.Nil(t, err)
+
+			err = s.Put(ctx, name, Value{Value: &value, Meta: Metadata{Secure: true}})
+			assert.Nil(t, err)
+
+			config, err := s.Get(ctx, name, -1)
+			assert.Nil(t, err)
+			assert.Equal(t, "pass", *config.Value)
+			assert.Equal(t, "/test/db/password", config.Meta.Key)
+			assert.Equal(t, 2, config.Meta.Version)
+			assert.True(t, config.Meta.Secure)
+
+			rawValues, err := s.ListRaw(ctx, "/test")
+			assert.Nil(t, err)
+			assert.Equal(t, []RawValue{{Value: "pass", Key: "/test/db/password"}}, rawValues)
+
+			config, err = s.Get(ctx, name, 1)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, config.Meta.Version)
+
+			history, err := s.History(ctx, name)
+			assert.Nil(t, err)
+			assert.Len(t, history, 2)
+			assert.Equal(t, 1, history[0].Meta.Version)
+			assert.Equal(t, 2, history[1].Meta.Version)
+
+			rawValues, err = s.ListRaw(ctx, "/tes")
+			assert.Nil(t, err)
+			assert.Empty(t, rawValues)
+
+			err = s.Delete(ctx, name)
+			assert.Nil(t, err)
+
+			configs, err := s.List(ctx, "/test", true)
+			assert.Nil(t, err)
+			assert.Empty(t, configs)
+
+			_, err = s.History(ctx, name)
+			assert.Equal(t, ErrConfigNotFound, err)
+		})
+	}
+}
+
+func TestFileStoreConcurrentPut(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "sicc")
+	assert.Nil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	s, err := NewFileStore(filepath.Join(dir, "store.json"))
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			value := strconv.Itoa(i)
+			err := s.Put(ctx, ParameterName{ParameterPath: "/test", Name: "key" + value}, Value{Value: &value})
+			assert.Nil(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	configs, err := s.List(ctx, "/test", false)
+	assert.Nil(t, err)
+	assert.Len(t, configs, 20)
+}
+
+func TestFileStoreFixture(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "sicc")
+	assert.Nil(t, err)
+
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "fixture.yml")
+
+	fixture := []byte(`
+/test/db/username: admin
+test/db/password:
+  value: pass
+  secure: true
+/test/db/port: 5432
+/test/db/ssl: true
+/test/db/ratio: 0.75
+`)
+
+	err = ioutil.WriteFile(filename, fixture, 0600)
+	assert.Nil(t, err)
+
+	s, err := NewFileStore(filename)
+	assert.Nil(t, err)
+
+	configs, err := s.List(ctx, "/test", true)
+	assert.Nil(t, err)
+	assert.Len(t, configs, 5)
+
+	assert.Equal(t, "/test/db/password", configs[0].Meta.Key)
+	assert.Equal(t, "pass", *configs[0].Value)
+	assert.True(t, configs[0].Meta.Secure)
+
+	assert.Equal(t, "/test/db/username", configs[4].Meta.Key)
+	assert.Equal(t, "admin", *configs[4].Value)
+	assert.False(t, configs[4].Meta.Secure)
+
+	rawValues, err := s.ListRaw(ctx, "/test")
+	assert.Nil(t, err)
+	assert.Contains(t, rawValues, RawValue{Key: "/test/db/port", Value: "5432"})
+	assert.Contains(t, rawValues, RawValue{Key: "/test/db/ssl", Value: "true"})
+	assert.Contains(t, rawValues, RawValue{Key: "/test/db/ratio", Value: "0.75"})
+
+	err = ioutil.WriteFile(filename, []byte("/test/db/hosts: [a, b]\n"), 0600)
+	assert.Nil(t, err)
+
+	_, err = s.List(ctx, "/test", true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,13 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!windows
+
+package store
+
+import (
+	"errors"
+)
+
+// lockFile fails, since file locks are not implemented on this platform, and
+// file stores could lose concurrent writes without them.
+func lockFile(filename string, exclusive bool) (func() error, error) {
+	return nil, errors.New("file locks are not supported on this platform")
+}
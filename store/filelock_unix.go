@@ -0,0 +1,32 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package store
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile acquires an advisory lock on the given file, creating it if
+// needed. The returned function releases the lock.
+func lockFile(filename string, exclusive bool) (func() error, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600) //nolint:gomnd
+	if err != nil {
+		return nil, err
+	}
+
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer f.Close()
+		return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	}, nil
+}
@@ -0,0 +1,51 @@
+// +build windows
+
+package store
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modkernel32      = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = modkernel32.NewProc("LockFileEx")
+	procUnlockFileEx = modkernel32.NewProc("UnlockFileEx")
+)
+
+// lockfileExclusiveLock requests exclusive lock from LockFileEx
+const lockfileExclusiveLock = 0x00000002
+
+// lockFile acquires a lock on the first byte of the given file, creating it
+// if needed. The returned function releases the lock.
+func lockFile(filename string, exclusive bool) (func() error, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600) //nolint:gomnd
+	if err != nil {
+		return nil, err
+	}
+
+	var flags uintptr
+	if exclusive {
+		flags = lockfileExclusiveLock
+	}
+
+	overlapped := &syscall.Overlapped{}
+
+	r, _, err := procLockFileEx.Call(f.Fd(), flags, 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+	if r == 0 {
+		f.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer f.Close()
+
+		r, _, err := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(overlapped)))
+		if r == 0 {
+			return err
+		}
+
+		return nil
+	}, nil
+}
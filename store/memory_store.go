@@ -1,150 +1,216 @@
 package store
 
 import (
+	"context"
+	"os/user"
 	"path"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
+// MemoryStore implements the Store interface for storing configurations in
+// memory. Keys are full parameter names, the same as with SSMStore.
 type MemoryStore struct {
-	m map[string]Value
+	// m holds all versions of configurations, the latest one is last
+	m map[string][]Value
 
 	mu sync.RWMutex
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		m: make(map[string]Value),
+		m: make(map[string][]Value),
 	}
 }
 
 func NewMemoryStoreFromMap(m map[string]string) *MemoryStore {
-	configs := map[string]Value{}
+	configs := map[string][]Value{}
 
 	for k, v := range m {
 		k := path.Join("/", k)
 		v := v
-		configs[k] = Value{
+		configs[k] = []Value{{
 			Value: &v,
-		}
+			Meta: Metadata{
+				Key:     k,
+				Version: 1,
+			},
+		}}
 	}
 
 	return &MemoryStore{m: configs}
 }
 
-func (s *MemoryStore) Put(name ParameterName, value Value) error {
+func (s *MemoryStore) Put(ctx context.Context, name ParameterName, value Value) error {
+	if value.Value == nil {
+		return ErrValueMissing
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	key := path.Join(name.ParameterPath, name.Name)
+	key := memoryStoreKey(name)
+
+	v := *value.Value
 
-	s.m[key] = value
+	meta := value.Meta
+	meta.Key = key
+	meta.Version = 1
+	meta.LastModifiedDate = time.Now().UTC()
+	meta.LastModifiedUser = currentUser()
+
+	if current, ok := s.latest(key); ok {
+		meta.Version = current.Meta.Version + 1
+	}
+
+	s.m[key] = append(s.m[key], Value{
+		Value: &v,
+		Meta:  meta,
+	})
 
 	return nil
 }
 
-func (s *MemoryStore) Get(name ParameterName, version int) (Value, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *MemoryStore) Get(ctx context.Context, name ParameterName, version int) (Value, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := memoryStoreKey(name)
+
+	if version == -1 {
+		if val, ok := s.latest(key); ok {
+			return val, nil
+		}
 
-	key := path.Join(name.ParameterPath, name.Name)
+		return Value{}, ErrConfigNotFound
+	}
 
-	if val, ok := s.m[key]; ok {
-		return val, nil
+	for _, val := range s.m[key] {
+		if val.Meta.Version == version {
+			return val, nil
+		}
 	}
 
 	return Value{}, ErrConfigNotFound
 }
 
-func (s *MemoryStore) List(prefix string, includeValues bool) ([]Value, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *MemoryStore) List(ctx context.Context, prefix string, includeValues bool) ([]Value, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	configs := map[string]Value{}
+	values := []Value{}
 
-	prefixPath := path.Join("/", prefix)
+	for _, k := range s.sortedKeys(prefix) {
+		v, _ := s.latest(k)
 
-	// sorted map range
-	keys := make([]string, 0, len(s.m))
-	for k := range s.m {
-		keys = append(keys, k)
+		if !includeValues {
+			v.Value = nil
+		}
+
+		values = append(values, v)
 	}
 
-	sort.Strings(keys)
+	return values, nil
+}
 
-	for _, k := range keys {
-		if strings.HasPrefix(k, prefixPath) {
-			key := strings.TrimPrefix(k, prefixPath)
-			configs[key] = Value{
-				Value: nil,
-			}
+func (s *MemoryStore) ListRaw(ctx context.Context, prefix string) ([]RawValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-			if includeValues {
-				v := s.m[k]
+	rawValues := []RawValue{}
 
-				value := configs[key]
-				value.Value = v.Value
-				configs[key] = value
-			}
-		}
-	}
+	for _, k := range s.sortedKeys(prefix) {
+		v, _ := s.latest(k)
 
-	values := []Value{}
-	for _, v := range configs {
-		values = append(values, v)
+		rawValues = append(rawValues, RawValue{
+			Value: *v.Value,
+			Key:   k,
+		})
 	}
 
-	return values, nil
+	return rawValues, nil
 }
 
-func (s *MemoryStore) ListRaw(prefix string) ([]RawValue, error) {
+func (s *MemoryStore) Delete(ctx context.Context, name ParameterName) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	values := map[string]RawValue{}
+	key := memoryStoreKey(name)
 
+	if _, ok := s.m[key]; !ok {
+		return ErrConfigNotFound
+	}
+
+	delete(s.m, key)
+
+	return nil
+}
+
+// History returns all versions of the configuration, oldest first.
+func (s *MemoryStore) History(ctx context.Context, name ParameterName) ([]Value, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := memoryStoreKey(name)
+
+	history, ok := s.m[key]
+	if !ok || len(history) == 0 {
+		return nil, ErrConfigNotFound
+	}
+
+	return append([]Value{}, history...), nil
+}
+
+// latest returns the latest version of the configuration.
+func (s *MemoryStore) latest(key string) (Value, bool) {
+	history := s.m[key]
+	if len(history) == 0 {
+		return Value{}, false
+	}
+
+	return history[len(history)-1], true
+}
+
+// sortedKeys returns sorted keys which are located under the given prefix.
+func (s *MemoryStore) sortedKeys(prefix string) []string {
 	prefixPath := path.Join("/", prefix)
 
-	// sorted map range
 	keys := make([]string, 0, len(s.m))
+
 	for k := range s.m {
-		keys = append(keys, k)
+		if HasPathPrefix(k, prefixPath) {
+			keys = append(keys, k)
+		}
 	}
 
 	sort.Strings(keys)
 
-	for _, k := range keys {
-		if strings.HasPrefix(k, prefixPath) {
-			v := s.m[k]
-			key := strings.TrimPrefix(k, prefixPath)
-			values[key] = RawValue{
-				Value: *v.Value,
-				Key:   key,
-			}
-		}
-	}
+	return keys
+}
 
-	rawValues := make([]RawValue, len(values))
-	i := 0
+func memoryStoreKey(name ParameterName) string {
+	return path.Join("/", name.ParameterPath, name.Name)
+}
 
-	for _, rawValue := range values {
-		rawValues[i] = rawValue
-		i++
+// HasPathPrefix reports whether key is located under the prefix path, the
+// same way SSM matches parameter hierarchies.
+func HasPathPrefix(key, prefixPath string) bool {
+	if prefixPath == "/" {
+		return true
 	}
 
-	return rawValues, nil
+	return key == prefixPath || strings.HasPrefix(key, prefixPath+"/")
 }
 
-func (s *MemoryStore) Delete(name ParameterName) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	key := path.Join(name.ParameterPath, name.Name)
-
-	delete(s.m, key)
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
 
-	return nil
+	return u.Username
 }
 
 // Check the interfaces are satisfied
@@ -0,0 +1,39 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	s := NewMemoryStore()
+
+	name := ParameterName{ParameterPath: "/test/db", Name: "password"}
+	value := "pass"
+
+	err := s.Put(ctx, name, Value{})
+	assert.Equal(t, ErrValueMissing, err)
+
+	err = s.Put(ctx, name, Value{Value: &value})
+	assert.Nil(t, err)
+
+	// keys are full parameter names, the same as with SSMStore
+	rawValues, err := s.ListRaw(ctx, "/test")
+	assert.Nil(t, err)
+	assert.Equal(t, []RawValue{{Value: "pass", Key: "/test/db/password"}}, rawValues)
+
+	rawValues, err = s.ListRaw(ctx, "/tes")
+	assert.Nil(t, err)
+	assert.Empty(t, rawValues)
+
+	err = s.Delete(ctx, name)
+	assert.Nil(t, err)
+
+	// deleting a missing configuration fails, the same as with SSMStore
+	err = s.Delete(ctx, name)
+	assert.Equal(t, ErrConfigNotFound, err)
+}
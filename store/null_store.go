@@ -1,6 +1,9 @@
 package store
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 type NullStore struct{}
 
@@ -8,26 +11,30 @@ func NewNullStore() *NullStore {
 	return &NullStore{}
 }
 
-func (s *NullStore) Put(name ParameterName, value Value) error {
+func (s *NullStore) Put(ctx context.Context, name ParameterName, value Value) error {
 	return errors.New("not implemented for Null store")
 }
 
-func (s *NullStore) Get(name ParameterName, version int) (Value, error) {
+func (s *NullStore) Get(ctx context.Context, name ParameterName, version int) (Value, error) {
 	return Value{}, errors.New("not implemented for Null store")
 }
 
-func (s *NullStore) List(prefix string, includeValues bool) ([]Value, error) {
+func (s *NullStore) List(ctx context.Context, prefix string, includeValues bool) ([]Value, error) {
 	return []Value{}, errors.New("not implemented for Null store")
 }
 
-func (s *NullStore) ListRaw(prefix string) ([]RawValue, error) {
+func (s *NullStore) ListRaw(ctx context.Context, prefix string) ([]RawValue, error) {
 	return []RawValue{}, errors.New("not implemented for Null store")
 }
 
-func (s *NullStore) Delete(name ParameterName) error {
+func (s *NullStore) Delete(ctx context.Context, name ParameterName) error {
 	return errors.New("not implemented for Null store")
 }
 
+func (s *NullStore) History(ctx context.Context, name ParameterName) ([]Value, error) {
+	return []Value{}, errors.New("not implemented for Null store")
+}
+
 // Check the interfaces are satisfied
 var (
 	_ Store = &NullStore{}
@@ -0,0 +1,474 @@
+//nolint:lll
+package store
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+)
+
+const (
+	// DefaultRecoveryWindowInDays is the number of days Secrets Manager waits
+	// before it permanently deletes a secret
+	DefaultRecoveryWindowInDays = 30
+
+	// MinRecoveryWindowInDays is the shortest recovery window accepted by
+	// Secrets Manager
+	MinRecoveryWindowInDays = 7
+
+	// MaxRecoveryWindowInDays is the longest recovery window accepted by
+	// Secrets Manager
+	MaxRecoveryWindowInDays = 30
+
+	// CurrentVersionStage is the staging label of the current secret version
+	CurrentVersionStage = "AWSCURRENT"
+
+	// PreviousVersionStage is the staging label of the previous secret version
+	PreviousVersionStage = "AWSPREVIOUS"
+
+	// versionIDPrefix precedes the version number in IDs of secret versions
+	// written by the store
+	versionIDPrefix = "sicc-version-"
+)
+
+// SecretsManagerStoreConfig holds settings used to create
+// SecretsManagerStore
+type SecretsManagerStoreConfig struct {
+	NumRetries int
+
+	// RecoveryWindowInDays is the number of days before deleted secret can
+	// no longer be restored; zero deletes immediately
+	RecoveryWindowInDays int
+
+	// Profile is the AWS shared configuration profile, defaults to the
+	// profile selected by environment
+	Profile string
+}
+
+// SecretsManagerStore implements the Store interface for storing
+// configurations in AWS Secrets Manager. All configurations are stored
+// encrypted, so they are always reported as secure.
+//
+// Secrets Manager identifies versions by ID, so the version number is written
+// into the ID of each version, e.g. sicc-version-00000000000000000003, one
+// more than the highest number of retained versions. Numbers do not change
+// when old versions are dropped. Versions not written by the store, e.g. by
+// rotation, have no number; they are reported as version 0 and can be read
+// only by their staging label.
+type SecretsManagerStore struct {
+	svc secretsmanageriface.SecretsManagerAPI
+
+	// recoveryWindowInDays is the number of days before deleted secret can
+	// no longer be restored; zero deletes immediately
+	recoveryWindowInDays int
+}
+
+// NewSecretsManagerStore creates a new SecretsManagerStore
+func NewSecretsManagerStore(config SecretsManagerStoreConfig) (*SecretsManagerStore, error) {
+	if err := validateRecoveryWindow(config.RecoveryWindowInDays); err != nil {
+		return nil, err
+	}
+
+	smSession, region, err := getSession(config.NumRetries, config.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := secretsmanager.New(smSession, &aws.Config{
+		MaxRetries: aws.Int(config.NumRetries),
+		Region:     region,
+	})
+
+	return &SecretsManagerStore{
+		svc:                  svc,
+		recoveryWindowInDays: config.RecoveryWindowInDays,
+	}, nil
+}
+
+// validateRecoveryWindow checks the recovery window is either zero (delete
+// immediately) or within the range accepted by Secrets Manager.
+func validateRecoveryWindow(days int) error {
+	if days != 0 && (days < MinRecoveryWindowInDays || days > MaxRecoveryWindowInDays) {
+		return fmt.Errorf("invalid recovery window of %d days, must be 0 or between %d and %d",
+			days, MinRecoveryWindowInDays, MaxRecoveryWindowInDays)
+	}
+
+	return nil
+}
+
+// Put adds a given value to a the system identified by name.
+// If the configuration already exists, then it writes a new version.
+func (s *SecretsManagerStore) Put(ctx context.Context, name ParameterName, value Value) error {
+	if value.Value == nil {
+		return ErrValueMissing
+	}
+
+	secretName := s.parameterNameToString(name)
+
+	versions, err := s.listVersions(ctx, secretName)
+	if err == ErrConfigNotFound {
+		_, err = s.svc.CreateSecretWithContext(ctx, &secretsmanager.CreateSecretInput{
+			Name:               aws.String(secretName),
+			SecretString:       value.Value,
+			ClientRequestToken: aws.String(versionID(1)),
+		})
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	latest := 0
+
+	for _, v := range versions {
+		if n := versionNumber(v.VersionId); n > latest {
+			latest = n
+		}
+	}
+
+	_, err = s.svc.PutSecretValueWithContext(ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:           aws.String(secretName),
+		SecretString:       value.Value,
+		ClientRequestToken: aws.String(versionID(latest + 1)),
+	})
+
+	return err
+}
+
+// Get reads a configuration from Secrets Manager at a specific version.
+// To grab the latest version, use -1 as the version number.
+func (s *SecretsManagerStore) Get(ctx context.Context, name ParameterName, version int) (Value, error) {
+	if version == -1 {
+		return s.GetStage(ctx, name, CurrentVersionStage)
+	}
+
+	if version < 1 {
+		return Value{}, ErrConfigNotFound
+	}
+
+	secretName := s.parameterNameToString(name)
+
+	return s.getSecretValue(ctx, secretName, &secretsmanager.GetSecretValueInput{
+		SecretId:  aws.String(secretName),
+		VersionId: aws.String(versionID(version)),
+	})
+}
+
+// GetStage reads a configuration version which has the given staging label
+// attached, e.g. AWSCURRENT, AWSPREVIOUS or AWSPENDING.
+func (s *SecretsManagerStore) GetStage(ctx context.Context, name ParameterName, stage string) (Value, error) {
+	secretName := s.parameterNameToString(name)
+
+	return s.getSecretValue(ctx, secretName, &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(secretName),
+		VersionStage: aws.String(stage),
+	})
+}
+
+func (s *SecretsManagerStore) List(ctx context.Context, prefix string, includeValues bool) ([]Value, error) {
+	secrets, err := s.listSecrets(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []Value{}
+
+	for _, secret := range secrets {
+		secretName := *secret.Name
+
+		if includeValues {
+			value, err := s.GetStage(ctx, ParameterName{Name: secretName}, CurrentVersionStage)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+
+			continue
+		}
+
+		versions, err := s.listVersions(ctx, secretName)
+		if err != nil {
+			return nil, err
+		}
+
+		meta := Metadata{
+			Key:    secretName,
+			Secure: true,
+		}
+
+		for _, v := range versions {
+			if hasVersionStage(v.VersionStages, CurrentVersionStage) {
+				meta.Version = versionNumber(v.VersionId)
+				meta.LastModifiedDate = aws.TimeValue(v.CreatedDate)
+			}
+		}
+
+		values = append(values, Value{
+			Value: nil,
+			Meta:  meta,
+		})
+	}
+
+	return values, nil
+}
+
+// ListRaw lists all configuration keys and values for a given prefix.
+// Does not include any other meta-data.
+func (s *SecretsManagerStore) ListRaw(ctx context.Context, prefix string) ([]RawValue, error) {
+	secrets, err := s.listSecrets(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	rawValues := make([]RawValue, 0, len(secrets))
+
+	for _, secret := range secrets {
+		resp, err := s.svc.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+			SecretId:     secret.Name,
+			VersionStage: aws.String(CurrentVersionStage),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		rawValues = append(rawValues, RawValue{
+			Value: secretValueString(resp),
+			Key:   *secret.Name,
+		})
+	}
+
+	return rawValues, nil
+}
+
+// Delete schedules deletion of a secret, including all versions. The secret
+// can be restored until recovery window passes.
+func (s *SecretsManagerStore) Delete(ctx context.Context, name ParameterName) error {
+	deleteSecretInput := &secretsmanager.DeleteSecretInput{
+		SecretId: aws.String(s.parameterNameToString(name)),
+	}
+
+	if s.recoveryWindowInDays > 0 {
+		deleteSecretInput.RecoveryWindowInDays = aws.Int64(int64(s.recoveryWindowInDays))
+	} else {
+		deleteSecretInput.ForceDeleteWithoutRecovery = aws.Bool(true)
+	}
+
+	_, err := s.svc.DeleteSecretWithContext(ctx, deleteSecretInput)
+	if err != nil {
+		if isSecretNotFound(err) {
+			return ErrConfigNotFound
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// History returns all versions of the secret, oldest first. Versions which
+// are no longer retrievable are skipped.
+func (s *SecretsManagerStore) History(ctx context.Context, name ParameterName) ([]Value, error) {
+	secretName := s.parameterNameToString(name)
+
+	versions, err := s.listVersions(ctx, secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []Value{}
+
+	for _, v := range versions {
+		value, err := s.getSecretValue(ctx, secretName, &secretsmanager.GetSecretValueInput{
+			SecretId:  aws.String(secretName),
+			VersionId: v.VersionId,
+		})
+		if err == ErrConfigNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	if len(values) == 0 {
+		return nil, ErrConfigNotFound
+	}
+
+	return values, nil
+}
+
+func (s *SecretsManagerStore) parameterNameToString(name ParameterName) string {
+	return path.Join([]string{"/", name.ParameterPath, name.Name}...)
+}
+
+func (s *SecretsManagerStore) getSecretValue(ctx context.Context, secretName string, input *secretsmanager.GetSecretValueInput) (Value, error) {
+	resp, err := s.svc.GetSecretValueWithContext(ctx, input)
+	if err != nil {
+		if isSecretNotFound(err) {
+			return Value{}, ErrConfigNotFound
+		}
+
+		return Value{}, err
+	}
+
+	value := secretValueString(resp)
+
+	meta := Metadata{
+		Key:              secretName,
+		Version:          versionNumber(resp.VersionId),
+		Secure:           true,
+		LastModifiedDate: aws.TimeValue(resp.CreatedDate),
+	}
+
+	return Value{
+		Value: &value,
+		Meta:  meta,
+	}, nil
+}
+
+// listSecrets lists all secrets located under the prefix, which are not
+// scheduled for deletion. Secrets are filtered by the name prefix in Secrets
+// Manager, which is not case sensitive and does not respect path segments, so
+// they are also filtered here.
+func (s *SecretsManagerStore) listSecrets(ctx context.Context, prefix string) ([]*secretsmanager.SecretListEntry, error) {
+	prefixPath := path.Join("/", prefix)
+
+	secrets := []*secretsmanager.SecretListEntry{}
+
+	listSecretsInput := &secretsmanager.ListSecretsInput{
+		Filters: []*secretsmanager.Filter{
+			{
+				Key:    aws.String(secretsmanager.FilterNameStringTypeName),
+				Values: []*string{aws.String(prefixPath)},
+			},
+		},
+	}
+
+	err := s.svc.ListSecretsPagesWithContext(ctx, listSecretsInput, func(resp *secretsmanager.ListSecretsOutput, lastPage bool) bool {
+		for _, secret := range resp.SecretList {
+			if secret.DeletedDate != nil {
+				continue
+			}
+
+			if !validPathKeyFormat.MatchString(*secret.Name) || !HasPathPrefix(*secret.Name, prefixPath) {
+				continue
+			}
+
+			secrets = append(secrets, secret)
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(secrets, func(i, j int) bool {
+		return *secrets[i].Name < *secrets[j].Name
+	})
+
+	return secrets, nil
+}
+
+// listVersions lists all versions of a secret, ordered by creation date.
+func (s *SecretsManagerStore) listVersions(ctx context.Context, secretName string) ([]*secretsmanager.SecretVersionsListEntry, error) {
+	versions := []*secretsmanager.SecretVersionsListEntry{}
+
+	listSecretVersionIdsInput := &secretsmanager.ListSecretVersionIdsInput{
+		SecretId:          aws.String(secretName),
+		IncludeDeprecated: aws.Bool(true),
+	}
+
+	err := s.svc.ListSecretVersionIdsPagesWithContext(ctx, listSecretVersionIdsInput, func(resp *secretsmanager.ListSecretVersionIdsOutput, lastPage bool) bool {
+		versions = append(versions, resp.Versions...)
+		return true
+	})
+	if err != nil {
+		if isSecretNotFound(err) {
+			return nil, ErrConfigNotFound
+		}
+
+		return nil, err
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return aws.TimeValue(versions[i].CreatedDate).Before(aws.TimeValue(versions[j].CreatedDate))
+	})
+
+	return versions, nil
+}
+
+// versionID returns the ID of the secret version with the given number.
+func versionID(version int) string {
+	return fmt.Sprintf("%s%020d", versionIDPrefix, version)
+}
+
+// versionNumber returns the number of the secret version from its ID, or 0
+// for versions not written by the store.
+func versionNumber(id *string) int {
+	s := aws.StringValue(id)
+	if !strings.HasPrefix(s, versionIDPrefix) {
+		return 0
+	}
+
+	n, err := strconv.Atoi(strings.TrimPrefix(s, versionIDPrefix))
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+func secretValueString(resp *secretsmanager.GetSecretValueOutput) string {
+	if resp.SecretString != nil {
+		return *resp.SecretString
+	}
+
+	return string(resp.SecretBinary)
+}
+
+func hasVersionStage(stages []*string, stage string) bool {
+	for _, s := range stages {
+		if aws.StringValue(s) == stage {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSecretNotFound(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == secretsmanager.ErrCodeResourceNotFoundException
+}
+
+// KeepsSecure returns false, all secrets are reported as secure.
+func (s *SecretsManagerStore) KeepsSecure() bool {
+	return false
+}
+
+// KeepsDescription returns false, descriptions are not written.
+func (s *SecretsManagerStore) KeepsDescription() bool {
+	return false
+}
+
+// Check the interfaces are satisfied
+var (
+	_ Store          = &SecretsManagerStore{}
+	_ MetadataKeeper = &SecretsManagerStore{}
+	_ StageStore     = &SecretsManagerStore{}
+)
@@ -0,0 +1,327 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSecretVersion struct {
+	id      string
+	value   string
+	created time.Time
+	stages  []*string
+}
+
+type fakeSecret struct {
+	versions    []*fakeSecretVersion
+	deletedDate *time.Time
+}
+
+// fakeSecretsManager is an in-memory stand-in of Secrets Manager.
+type fakeSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+
+	secrets map[string]*fakeSecret
+	now     time.Time
+	deleted map[string]*secretsmanager.DeleteSecretInput
+}
+
+func newFakeSecretsManager() *fakeSecretsManager {
+	return &fakeSecretsManager{
+		secrets: map[string]*fakeSecret{},
+		now:     time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		deleted: map[string]*secretsmanager.DeleteSecretInput{},
+	}
+}
+
+func (m *fakeSecretsManager) notFound() error {
+	return awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "secret not found", nil)
+}
+
+// addVersion adds the current version, with the given ID or generated one.
+func (m *fakeSecretsManager) addVersion(secret *fakeSecret, value string, id *string) *fakeSecretVersion {
+	m.now = m.now.Add(time.Minute)
+
+	for _, v := range secret.versions {
+		v.stages = nil
+	}
+
+	if n := len(secret.versions); n > 0 {
+		secret.versions[n-1].stages = []*string{aws.String(PreviousVersionStage)}
+	}
+
+	if id == nil {
+		id = aws.String(fmt.Sprintf("%032d", len(secret.versions)+1))
+	}
+
+	version := &fakeSecretVersion{
+		id:      *id,
+		value:   value,
+		created: m.now,
+		stages:  []*string{aws.String(CurrentVersionStage)},
+	}
+
+	secret.versions = append(secret.versions, version)
+
+	return version
+}
+
+func (m *fakeSecretsManager) CreateSecretWithContext(ctx aws.Context, input *secretsmanager.CreateSecretInput, opts ...request.Option) (*secretsmanager.CreateSecretOutput, error) {
+	if _, ok := m.secrets[*input.Name]; ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceExistsException, "secret exists", nil)
+	}
+
+	secret := &fakeSecret{}
+	m.secrets[*input.Name] = secret
+	version := m.addVersion(secret, *input.SecretString, input.ClientRequestToken)
+
+	return &secretsmanager.CreateSecretOutput{Name: input.Name, VersionId: aws.String(version.id)}, nil
+}
+
+func (m *fakeSecretsManager) PutSecretValueWithContext(ctx aws.Context, input *secretsmanager.PutSecretValueInput, opts ...request.Option) (*secretsmanager.PutSecretValueOutput, error) {
+	secret, ok := m.secrets[*input.SecretId]
+	if !ok {
+		return nil, m.notFound()
+	}
+
+	version := m.addVersion(secret, *input.SecretString, input.ClientRequestToken)
+
+	return &secretsmanager.PutSecretValueOutput{Name: input.SecretId, VersionId: aws.String(version.id)}, nil
+}
+
+func (m *fakeSecretsManager) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	secret, ok := m.secrets[*input.SecretId]
+	if !ok {
+		return nil, m.notFound()
+	}
+
+	for _, v := range secret.versions {
+		if aws.StringValue(input.VersionId) == v.id || hasVersionStage(v.stages, aws.StringValue(input.VersionStage)) {
+			return &secretsmanager.GetSecretValueOutput{
+				Name:          input.SecretId,
+				SecretString:  aws.String(v.value),
+				VersionId:     aws.String(v.id),
+				VersionStages: v.stages,
+				CreatedDate:   aws.Time(v.created),
+			}, nil
+		}
+	}
+
+	return nil, m.notFound()
+}
+
+func (m *fakeSecretsManager) ListSecretVersionIdsPagesWithContext(ctx aws.Context, input *secretsmanager.ListSecretVersionIdsInput, fn func(*secretsmanager.ListSecretVersionIdsOutput, bool) bool, opts ...request.Option) error {
+	secret, ok := m.secrets[*input.SecretId]
+	if !ok {
+		return m.notFound()
+	}
+
+	versions := []*secretsmanager.SecretVersionsListEntry{}
+
+	// newest first, the same as Secrets Manager
+	for i := len(secret.versions) - 1; i >= 0; i-- {
+		v := secret.versions[i]
+		versions = append(versions, &secretsmanager.SecretVersionsListEntry{
+			VersionId:     aws.String(v.id),
+			VersionStages: v.stages,
+			CreatedDate:   aws.Time(v.created),
+		})
+	}
+
+	fn(&secretsmanager.ListSecretVersionIdsOutput{Versions: versions}, true)
+
+	return nil
+}
+
+func (m *fakeSecretsManager) ListSecretsPagesWithContext(ctx aws.Context, input *secretsmanager.ListSecretsInput, fn func(*secretsmanager.ListSecretsOutput, bool) bool, opts ...request.Option) error {
+	secrets := []*secretsmanager.SecretListEntry{}
+
+	for name, secret := range m.secrets {
+		if !fakeMatchesFilters(name, input.Filters) {
+			continue
+		}
+
+		secrets = append(secrets, &secretsmanager.SecretListEntry{
+			Name:        aws.String(name),
+			DeletedDate: secret.deletedDate,
+		})
+	}
+
+	fn(&secretsmanager.ListSecretsOutput{SecretList: secrets}, true)
+
+	return nil
+}
+
+// fakeMatchesFilters matches the secret name with name filters of the list,
+// as prefix not case sensitive.
+func fakeMatchesFilters(name string, filters []*secretsmanager.Filter) bool {
+	for _, filter := range filters {
+		if aws.StringValue(filter.Key) != secretsmanager.FilterNameStringTypeName {
+			continue
+		}
+
+		for _, value := range filter.Values {
+			if !strings.HasPrefix(strings.ToLower(name), strings.ToLower(aws.StringValue(value))) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (m *fakeSecretsManager) DeleteSecretWithContext(ctx aws.Context, input *secretsmanager.DeleteSecretInput, opts ...request.Option) (*secretsmanager.DeleteSecretOutput, error) {
+	secret, ok := m.secrets[*input.SecretId]
+	if !ok {
+		return nil, m.notFound()
+	}
+
+	secret.deletedDate = aws.Time(m.now)
+	m.deleted[*input.SecretId] = input
+
+	return &secretsmanager.DeleteSecretOutput{Name: input.SecretId}, nil
+}
+
+//nolint:funlen
+func TestSecretsManagerStore(t *testing.T) {
+	ctx := context.Background()
+
+	fake := newFakeSecretsManager()
+
+	s := &SecretsManagerStore{
+		svc:                  fake,
+		recoveryWindowInDays: 7,
+	}
+
+	name := ParameterName{ParameterPath: "/test/tls", Name: "cert"}
+
+	_, err := s.Get(ctx, name, -1)
+	assert.Equal(t, ErrConfigNotFound, err)
+
+	err = s.Put(ctx, name, Value{})
+	assert.Equal(t, ErrValueMissing, err)
+
+	for _, v := range []string{"cert1", "cert2", "cert3"} {
+		v := v
+		err = s.Put(ctx, name, Value{Value: &v})
+		assert.Nil(t, err)
+	}
+
+	other := "value"
+	err = s.Put(ctx, ParameterName{ParameterPath: "/testing", Name: "other"}, Value{Value: &other})
+	assert.Nil(t, err)
+
+	err = s.Put(ctx, ParameterName{ParameterPath: "/Test", Name: "upper"}, Value{Value: &other})
+	assert.Nil(t, err)
+
+	config, err := s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "cert3", *config.Value)
+	assert.Equal(t, "/test/tls/cert", config.Meta.Key)
+	assert.Equal(t, 3, config.Meta.Version)
+	assert.True(t, config.Meta.Secure)
+
+	config, err = s.Get(ctx, name, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "cert1", *config.Value)
+	assert.Equal(t, 1, config.Meta.Version)
+
+	config, err = s.GetStage(ctx, name, PreviousVersionStage)
+	assert.Nil(t, err)
+	assert.Equal(t, "cert2", *config.Value)
+	assert.Equal(t, 2, config.Meta.Version)
+
+	config, err = NewExpandingStore(s).GetStage(ctx, name, PreviousVersionStage)
+	assert.Nil(t, err)
+	assert.Equal(t, "cert2", *config.Value)
+
+	_, err = s.Get(ctx, name, 4)
+	assert.Equal(t, ErrConfigNotFound, err)
+
+	history, err := s.History(ctx, name)
+	assert.Nil(t, err)
+	assert.Len(t, history, 3)
+	assert.Equal(t, "cert1", *history[0].Value)
+	assert.Equal(t, 3, history[2].Meta.Version)
+
+	// Secrets Manager drops versions without staging labels
+	fake.secrets["/test/tls/cert"].versions = fake.secrets["/test/tls/cert"].versions[1:]
+
+	_, err = s.Get(ctx, name, 1)
+	assert.Equal(t, ErrConfigNotFound, err)
+
+	config, err = s.Get(ctx, name, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "cert2", *config.Value)
+	assert.Equal(t, 2, config.Meta.Version)
+
+	history, err = s.History(ctx, name)
+	assert.Nil(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, 2, history[0].Meta.Version)
+
+	cert := "cert4"
+	err = s.Put(ctx, name, Value{Value: &cert})
+	assert.Nil(t, err)
+
+	config, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, config.Meta.Version)
+
+	// versions not written by the store, e.g. by rotation, have no number
+	fake.addVersion(fake.secrets["/test/tls/cert"], "cert5", nil)
+
+	config, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "cert5", *config.Value)
+	assert.Equal(t, 0, config.Meta.Version)
+
+	cert = "cert6"
+	err = s.Put(ctx, name, Value{Value: &cert})
+	assert.Nil(t, err)
+
+	config, err = s.Get(ctx, name, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, "cert6", *config.Value)
+
+	rawValues, err := s.ListRaw(ctx, "/test")
+	assert.Nil(t, err)
+	assert.Equal(t, []RawValue{{Value: "cert6", Key: "/test/tls/cert"}}, rawValues)
+
+	configs, err := s.List(ctx, "/test", false)
+	assert.Nil(t, err)
+	assert.Len(t, configs, 1)
+	assert.Nil(t, configs[0].Value)
+	assert.Equal(t, 5, configs[0].Meta.Version)
+
+	err = s.Delete(ctx, name)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), aws.Int64Value(fake.deleted["/test/tls/cert"].RecoveryWindowInDays))
+
+	rawValues, err = s.ListRaw(ctx, "/test")
+	assert.Nil(t, err)
+	assert.Empty(t, rawValues)
+
+	err = s.Delete(ctx, ParameterName{ParameterPath: "/test", Name: "missing"})
+	assert.Equal(t, ErrConfigNotFound, err)
+}
+
+func TestValidateRecoveryWindow(t *testing.T) {
+	for _, days := range []int{0, 7, 30} {
+		assert.Nil(t, validateRecoveryWindow(days))
+	}
+
+	for _, days := range []int{-1, 1, 6, 31} {
+		assert.Error(t, validateRecoveryWindow(days))
+	}
+}
@@ -2,10 +2,12 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -24,64 +26,113 @@ const (
 // parameter store when using paths
 var validPathKeyFormat = regexp.MustCompile(`^(\/[\w\-\.]+)+$`)
 
+// SSMStoreConfig holds settings of SSMStore
+type SSMStoreConfig struct {
+	NumRetries int
+
+	// LegacyVersions enables compatibility mode, where version numbers are
+	// kept in parameter description instead of using native SSM versions
+	LegacyVersions bool
+
+	// KMSKey is the key used to encrypt secure parameters, defaults to
+	// account's default SSM key
+	KMSKey string
+
+	// KMSKeyByPrefix maps parameter path prefixes to keys used instead of
+	// KMSKey; the longest matching prefix wins
+	KMSKeyByPrefix map[string]string
+
+	// Profile is the AWS shared configuration profile, defaults to the
+	// profile selected by environment
+	Profile string
+}
+
 // SSMStore implements the Store interface for storing configurations in
 // SSM Parameter Store
 type SSMStore struct {
 	svc ssmiface.SSMAPI
+
+	config SSMStoreConfig
 }
 
 // NewSSMStore creates a new SSMStore
-func NewSSMStore(numRetries int) (*SSMStore, error) {
-	ssmSession, region, err := getSession(numRetries)
+func NewSSMStore(config SSMStoreConfig) (*SSMStore, error) {
+	ssmSession, region, err := getSession(config.NumRetries, config.Profile)
 	if err != nil {
 		return nil, err
 	}
 
 	svc := ssm.New(ssmSession, &aws.Config{
-		MaxRetries: aws.Int(numRetries),
+		MaxRetries: aws.Int(config.NumRetries),
 		Region:     region,
 	})
 
 	return &SSMStore{
-		svc: svc,
+		svc:    svc,
+		config: config,
 	}, nil
 }
 
+// KMSKey returns the default key used to encrypt secure parameters.
 func (s *SSMStore) KMSKey() string {
+	if s.config.KMSKey != "" {
+		return s.config.KMSKey
+	}
+
 	return fmt.Sprintf("alias/%s", AccountDefaultSSMAliasKeyID)
 }
 
-// Put adds a given value to a the system identified by name.
-// If the configuration already exists, then it writes a new version.
-func (s *SSMStore) Put(name ParameterName, value Value) error {
-	version := 1
+// KMSKeyFor returns the key used to encrypt the given secure parameter.
+func (s *SSMStore) KMSKeyFor(name ParameterName) string {
+	parameterName := s.parameterNameToString(name)
 
-	// first read to get the current version
-	current, err := s.Get(name, -1)
-	if err != nil && !errors.Is(err, ErrConfigNotFound) {
-		return err
-	}
+	key := s.KMSKey()
+	longestPrefix := ""
 
-	//nolint:gomnd
-	if err == nil {
-		version = current.Meta.Version + 1
+	for prefix, prefixKey := range s.config.KMSKeyByPrefix {
+		prefixPath := path.Join("/", prefix)
+
+		if HasPathPrefix(parameterName, prefixPath) && len(prefixPath) > len(longestPrefix) {
+			key = prefixKey
+			longestPrefix = prefixPath
+		}
 	}
 
+	return key
+}
+
+// Put adds a given value to a the system identified by name.
+// If the configuration already exists, then it writes a new version.
+func (s *SSMStore) Put(ctx context.Context, name ParameterName, value Value) error {
+	// description is always sent, as SSM keeps the current one when it is
+	// missing, so empty description clears it the same as with other stores
 	putParameterInput := &ssm.PutParameterInput{
 		Name:        aws.String(s.parameterNameToString(name)),
 		Type:        aws.String("String"),
 		Value:       value.Value,
+		Description: aws.String(value.Meta.Description),
 		Overwrite:   aws.Bool(true),
-		Description: aws.String(strconv.Itoa(version)),
+	}
+
+	if s.config.LegacyVersions {
+		description, err := s.nextLegacyVersion(ctx, name, value)
+		if err != nil {
+			return err
+		}
+
+		putParameterInput.Description = aws.String(description)
 	}
 
 	if value.Meta.Secure {
 		putParameterInput.Type = aws.String("SecureString")
-		putParameterInput.KeyId = aws.String(s.KMSKey())
+		putParameterInput.KeyId = aws.String(s.KMSKeyFor(name))
+
+		if value.Meta.KeyID != "" {
+			putParameterInput.KeyId = aws.String(value.Meta.KeyID)
+		}
 	}
 
-	// This API call returns an empty struct
-	_, err = s.svc.PutParameter(putParameterInput)
+	_, err := s.svc.PutParameterWithContext(ctx, putParameterInput)
 	if err != nil {
 		return err
 	}
@@ -89,18 +140,41 @@ func (s *SSMStore) Put(name ParameterName, value Value) error {
 	return nil
 }
 
+// nextLegacyVersion returns the description holding the version number for
+// the next version of parameter, when in compatibility mode.
+func (s *SSMStore) nextLegacyVersion(ctx context.Context, name ParameterName, value Value) (string, error) {
+	if value.Meta.Description != "" {
+		return "", errors.New("description can not be set when using legacy versions")
+	}
+
+	version := 1
+
+	// first read to get the current version
+	current, err := s.Get(ctx, name, -1)
+	if err != nil && !errors.Is(err, ErrConfigNotFound) {
+		return "", err
+	}
+
+	//nolint:gomnd
+	if err == nil {
+		version = current.Meta.Version + 1
+	}
+
+	return strconv.Itoa(version), nil
+}
+
 // Get reads a configuration from the parameter store at a specific version.
 // To grab the latest version, use -1 as the version number.
-func (s *SSMStore) Get(name ParameterName, version int) (Value, error) {
+func (s *SSMStore) Get(ctx context.Context, name ParameterName, version int) (Value, error) {
 	if version == -1 {
-		return s.getLatest(name)
+		return s.getLatest(ctx, name)
 	}
 
-	return s.getVersion(name, version)
+	return s.getVersion(ctx, name, version)
 }
 
 //nolint:funlen
-func (s *SSMStore) List(prefix string, includeValues bool) ([]Value, error) {
+func (s *SSMStore) List(ctx context.Context, prefix string, includeValues bool) ([]Value, error) {
 	configs := map[string]Value{}
 
 	describeParametersInput := &ssm.DescribeParametersInput{
@@ -113,13 +187,13 @@ func (s *SSMStore) List(prefix string, includeValues bool) ([]Value, error) {
 		},
 	}
 
-	err := s.svc.DescribeParametersPages(describeParametersInput, func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
+	err := s.svc.DescribeParametersPagesWithContext(ctx, describeParametersInput, func(resp *ssm.DescribeParametersOutput, lastPage bool) bool {
 		for _, meta := range resp.Parameters {
 			if !s.validateName(*meta.Name) {
 				continue
 			}
 
-			configMeta := parameterMetaToValueMeta(meta)
+			configMeta := s.parameterMetaToValueMeta(meta)
 
 			configs[configMeta.Key] = Value{
 				Value: nil,
@@ -149,7 +223,7 @@ func (s *SSMStore) List(prefix string, includeValues bool) ([]Value, error) {
 				WithDecryption: aws.Bool(true),
 			}
 
-			resp, err := s.svc.GetParameters(getParametersInput)
+			resp, err := s.svc.GetParametersWithContext(ctx, getParametersInput)
 			if err != nil {
 				return nil, err
 			}
@@ -169,7 +243,7 @@ func (s *SSMStore) List(prefix string, includeValues bool) ([]Value, error) {
 // Does not include any other meta-data. Uses faster AWS APIs with much higher
 // rate-limits.
 // Suitable for use in production environments.
-func (s *SSMStore) ListRaw(prefix string) ([]RawValue, error) {
+func (s *SSMStore) ListRaw(ctx context.Context, prefix string) ([]RawValue, error) {
 	values := map[string]RawValue{}
 
 	getParametersByPathInput := &ssm.GetParametersByPathInput{
@@ -178,7 +252,7 @@ func (s *SSMStore) ListRaw(prefix string) ([]RawValue, error) {
 		WithDecryption: aws.Bool(true),
 	}
 
-	err := s.svc.GetParametersByPathPages(getParametersByPathInput, func(resp *ssm.GetParametersByPathOutput, lastPage bool) bool {
+	err := s.svc.GetParametersByPathPagesWithContext(ctx, getParametersByPathInput, func(resp *ssm.GetParametersByPathOutput, lastPage bool) bool {
 		for _, param := range resp.Parameters {
 			if !s.validateName(*param.Name) {
 				continue
@@ -216,9 +290,9 @@ func (s *SSMStore) ListRaw(prefix string) ([]RawValue, error) {
 
 // Delete removes a configuration from the parameter store. Note this removes
 // all versions of the configuration.
-func (s *SSMStore) Delete(name ParameterName) error {
+func (s *SSMStore) Delete(ctx context.Context, name ParameterName) error {
 	// first read to ensure parameter present
-	_, err := s.Get(name, -1)
+	_, err := s.Get(ctx, name, -1)
 	if err != nil {
 		return err
 	}
@@ -227,7 +301,7 @@ func (s *SSMStore) Delete(name ParameterName) error {
 		Name: aws.String(s.parameterNameToString(name)),
 	}
 
-	_, err = s.svc.DeleteParameter(deleteParameterInput)
+	_, err = s.svc.DeleteParameterWithContext(ctx, deleteParameterInput)
 	if err != nil {
 		return err
 	}
@@ -235,11 +309,48 @@ func (s *SSMStore) Delete(name ParameterName) error {
 	return nil
 }
 
+// History returns all versions of the configuration kept by SSM, oldest
+// first.
+func (s *SSMStore) History(ctx context.Context, name ParameterName) ([]Value, error) {
+	getParameterHistoryInput := &ssm.GetParameterHistoryInput{
+		Name:           aws.String(s.parameterNameToString(name)),
+		WithDecryption: aws.Bool(true),
+	}
+
+	values := []Value{}
+
+	if err := s.svc.GetParameterHistoryPagesWithContext(ctx, getParameterHistoryInput, func(o *ssm.GetParameterHistoryOutput, lastPage bool) bool {
+		for _, history := range o.Parameters {
+			values = append(values, Value{
+				Value: history.Value,
+				Meta:  s.parameterHistoryToValueMeta(history),
+			})
+		}
+		return true
+	}); err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+			return nil, ErrConfigNotFound
+		}
+
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, ErrConfigNotFound
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].Meta.Version < values[j].Meta.Version
+	})
+
+	return values, nil
+}
+
 func (s *SSMStore) parameterNameToString(name ParameterName) string {
 	return path.Join([]string{"/", name.ParameterPath, name.Name}...)
 }
 
-func (s *SSMStore) getVersion(name ParameterName, version int) (Value, error) {
+func (s *SSMStore) getVersion(ctx context.Context, name ParameterName, version int) (Value, error) {
 	getParameterHistoryInput := &ssm.GetParameterHistoryInput{
 		Name:           aws.String(s.parameterNameToString(name)),
 		WithDecryption: aws.Bool(true),
@@ -247,23 +358,13 @@ func (s *SSMStore) getVersion(name ParameterName, version int) (Value, error) {
 
 	var result Value
 
-	if err := s.svc.GetParameterHistoryPages(getParameterHistoryInput, func(o *ssm.GetParameterHistoryOutput, lastPage bool) bool {
+	if err := s.svc.GetParameterHistoryPagesWithContext(ctx, getParameterHistoryInput, func(o *ssm.GetParameterHistoryOutput, lastPage bool) bool {
 		for _, history := range o.Parameters {
-			thisVersion := 0
-			if history.Description != nil {
-				thisVersion, _ = strconv.Atoi(*history.Description)
-			}
-			if thisVersion == version {
+			meta := s.parameterHistoryToValueMeta(history)
+			if meta.Version == version {
 				result = Value{
 					Value: history.Value,
-					Meta: Metadata{
-						Key:              *history.Name,
-						Description:      *history.Description,
-						Secure:           (*history.Type == "SecureString"),
-						Version:          thisVersion,
-						LastModifiedDate: *history.LastModifiedDate,
-						LastModifiedUser: *history.LastModifiedUser,
-					},
+					Meta:  meta,
 				}
 
 				return false
@@ -281,7 +382,7 @@ func (s *SSMStore) getVersion(name ParameterName, version int) (Value, error) {
 	return Value{}, ErrConfigNotFound
 }
 
-func (s *SSMStore) getLatest(name ParameterName) (Value, error) {
+func (s *SSMStore) getLatest(ctx context.Context, name ParameterName) (Value, error) {
 	parameterNameString := s.parameterNameToString(name)
 
 	getParametersInput := &ssm.GetParametersInput{
@@ -289,7 +390,7 @@ func (s *SSMStore) getLatest(name ParameterName) (Value, error) {
 		WithDecryption: aws.Bool(true),
 	}
 
-	resp, err := s.svc.GetParameters(getParametersInput)
+	resp, err := s.svc.GetParametersWithContext(ctx, getParametersInput)
 	if err != nil {
 		return Value{}, err
 	}
@@ -317,7 +418,7 @@ func (s *SSMStore) getLatest(name ParameterName) (Value, error) {
 		},
 	}
 
-	if err := s.svc.DescribeParametersPages(describeParametersInput, func(o *ssm.DescribeParametersOutput, lastPage bool) bool {
+	if err := s.svc.DescribeParametersPagesWithContext(ctx, describeParametersInput, func(o *ssm.DescribeParametersOutput, lastPage bool) bool {
 		for _, param := range o.Parameters {
 			if *param.Name == parameterNameString {
 				parameter = param
@@ -333,7 +434,7 @@ func (s *SSMStore) getLatest(name ParameterName) (Value, error) {
 		return Value{}, ErrConfigNotFound
 	}
 
-	configMeta := parameterMetaToValueMeta(parameter)
+	configMeta := s.parameterMetaToValueMeta(parameter)
 
 	return Value{
 		Value: param.Value,
@@ -345,21 +446,56 @@ func (s *SSMStore) validateName(name string) bool {
 	return validPathKeyFormat.MatchString(name)
 }
 
-func parameterMetaToValueMeta(p *ssm.ParameterMetadata) Metadata {
-	version := 0
-
-	if p.Description != nil {
-		version, _ = strconv.Atoi(*p.Description)
+func (s *SSMStore) parameterMetaToValueMeta(p *ssm.ParameterMetadata) Metadata {
+	meta := Metadata{
+		Key:              *p.Name,
+		Secure:           (*p.Type == "SecureString"),
+		KeyID:            aws.StringValue(p.KeyId),
+		LastModifiedDate: aws.TimeValue(p.LastModifiedDate),
+		LastModifiedUser: aws.StringValue(p.LastModifiedUser),
 	}
 
-	return Metadata{
+	s.setVersionAndDescription(&meta, aws.Int64Value(p.Version), aws.StringValue(p.Description))
+
+	return meta
+}
+
+func (s *SSMStore) parameterHistoryToValueMeta(p *ssm.ParameterHistory) Metadata {
+	meta := Metadata{
 		Key:              *p.Name,
-		Description:      *p.Description,
 		Secure:           (*p.Type == "SecureString"),
-		Version:          version,
-		LastModifiedDate: *p.LastModifiedDate,
-		LastModifiedUser: *p.LastModifiedUser,
+		KeyID:            aws.StringValue(p.KeyId),
+		LastModifiedDate: aws.TimeValue(p.LastModifiedDate),
+		LastModifiedUser: aws.StringValue(p.LastModifiedUser),
 	}
+
+	s.setVersionAndDescription(&meta, aws.Int64Value(p.Version), aws.StringValue(p.Description))
+
+	return meta
+}
+
+// setVersionAndDescription sets version either from native SSM version, or
+// from description when in compatibility mode. In compatibility mode the
+// description holds the version number, so it is not exposed.
+func (s *SSMStore) setVersionAndDescription(meta *Metadata, version int64, description string) {
+	if s.config.LegacyVersions {
+		meta.Version, _ = strconv.Atoi(description)
+		return
+	}
+
+	meta.Version = int(version)
+	meta.Description = description
+}
+
+// KeepsSecure returns true, the secure flag is kept as parameter type.
+func (s *SSMStore) KeepsSecure() bool {
+	return true
+}
+
+// KeepsDescription returns false in compatibility mode, where the description
+// holds the version number.
+func (s *SSMStore) KeepsDescription() bool {
+	return !s.config.LegacyVersions
 }
 
 func keys(m map[string]Value) []string {
@@ -382,5 +518,7 @@ func values(m map[string]Value) []Value {
 
 // Check the interfaces are satisfied
 var (
-	_ Store = &SSMStore{}
+	_ Store          = &SSMStore{}
+	_ KMSKeyStore    = &SSMStore{}
+	_ MetadataKeeper = &SSMStore{}
 )
@@ -0,0 +1,280 @@
+package store
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSSM is an in-memory stand-in of SSM Parameter Store, keeping history
+// of every parameter.
+type fakeSSM struct {
+	ssmiface.SSMAPI
+
+	parameters map[string][]*ssm.ParameterHistory
+	now        time.Time
+}
+
+func newFakeSSM() *fakeSSM {
+	return &fakeSSM{
+		parameters: map[string][]*ssm.ParameterHistory{},
+		now:        time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (m *fakeSSM) latest(name string) *ssm.ParameterHistory {
+	history := m.parameters[name]
+	if len(history) == 0 {
+		return nil
+	}
+
+	return history[len(history)-1]
+}
+
+func (m *fakeSSM) PutParameterWithContext(ctx aws.Context, input *ssm.PutParameterInput, opts ...request.Option) (*ssm.PutParameterOutput, error) {
+	m.now = m.now.Add(time.Minute)
+
+	description := input.Description
+	if current := m.latest(*input.Name); current != nil && description == nil {
+		description = current.Description
+	}
+
+	version := int64(len(m.parameters[*input.Name]) + 1)
+
+	m.parameters[*input.Name] = append(m.parameters[*input.Name], &ssm.ParameterHistory{
+		Name:             input.Name,
+		Value:            input.Value,
+		Type:             input.Type,
+		KeyId:            input.KeyId,
+		Description:      description,
+		Version:          aws.Int64(version),
+		LastModifiedDate: aws.Time(m.now),
+		LastModifiedUser: aws.String("arn:aws:iam::123456789012:user/test"),
+	})
+
+	return &ssm.PutParameterOutput{Version: aws.Int64(version)}, nil
+}
+
+func (m *fakeSSM) GetParametersWithContext(ctx aws.Context, input *ssm.GetParametersInput, opts ...request.Option) (*ssm.GetParametersOutput, error) {
+	resp := &ssm.GetParametersOutput{}
+
+	for _, name := range input.Names {
+		if p := m.latest(*name); p != nil {
+			resp.Parameters = append(resp.Parameters, &ssm.Parameter{
+				Name:             p.Name,
+				Value:            p.Value,
+				Type:             p.Type,
+				Version:          p.Version,
+				LastModifiedDate: p.LastModifiedDate,
+			})
+		} else {
+			resp.InvalidParameters = append(resp.InvalidParameters, name)
+		}
+	}
+
+	return resp, nil
+}
+
+func (m *fakeSSM) DescribeParametersPagesWithContext(ctx aws.Context, input *ssm.DescribeParametersInput, fn func(*ssm.DescribeParametersOutput, bool) bool, opts ...request.Option) error {
+	filter := input.ParameterFilters[0]
+	prefix := *filter.Values[0]
+
+	resp := &ssm.DescribeParametersOutput{}
+
+	for name := range m.parameters {
+		p := m.latest(name)
+
+		if *filter.Option == "OneLevel" && path.Dir(name) != prefix {
+			continue
+		}
+
+		if !HasPathPrefix(name, prefix) {
+			continue
+		}
+
+		resp.Parameters = append(resp.Parameters, &ssm.ParameterMetadata{
+			Name:             p.Name,
+			Type:             p.Type,
+			KeyId:            p.KeyId,
+			Description:      p.Description,
+			Version:          p.Version,
+			LastModifiedDate: p.LastModifiedDate,
+			LastModifiedUser: p.LastModifiedUser,
+		})
+	}
+
+	fn(resp, true)
+
+	return nil
+}
+
+func (m *fakeSSM) GetParameterHistoryPagesWithContext(ctx aws.Context, input *ssm.GetParameterHistoryInput, fn func(*ssm.GetParameterHistoryOutput, bool) bool, opts ...request.Option) error {
+	fn(&ssm.GetParameterHistoryOutput{Parameters: m.parameters[*input.Name]}, true)
+	return nil
+}
+
+func (m *fakeSSM) GetParametersByPathPagesWithContext(ctx aws.Context, input *ssm.GetParametersByPathInput, fn func(*ssm.GetParametersByPathOutput, bool) bool, opts ...request.Option) error {
+	resp := &ssm.GetParametersByPathOutput{}
+
+	for name := range m.parameters {
+		if strings.HasPrefix(name, *input.Path+"/") {
+			p := m.latest(name)
+			resp.Parameters = append(resp.Parameters, &ssm.Parameter{Name: p.Name, Value: p.Value, Type: p.Type, Version: p.Version})
+		}
+	}
+
+	fn(resp, true)
+
+	return nil
+}
+
+func (m *fakeSSM) DeleteParameterWithContext(ctx aws.Context, input *ssm.DeleteParameterInput, opts ...request.Option) (*ssm.DeleteParameterOutput, error) {
+	delete(m.parameters, *input.Name)
+	return &ssm.DeleteParameterOutput{}, nil
+}
+
+func TestSSMStoreVersions(t *testing.T) {
+	ctx := context.Background()
+
+	fake := newFakeSSM()
+	s := &SSMStore{svc: fake}
+
+	name := ParameterName{ParameterPath: "/test/db", Name: "password"}
+
+	for _, v := range []string{"pass1", "pass2"} {
+		v := v
+		err := s.Put(ctx, name, Value{Value: &v, Meta: Metadata{Secure: true, Description: "database password"}})
+		assert.Nil(t, err)
+	}
+
+	config, err := s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass2", *config.Value)
+	assert.Equal(t, 2, config.Meta.Version)
+	assert.Equal(t, "database password", config.Meta.Description)
+	assert.True(t, config.Meta.Secure)
+
+	config, err = s.Get(ctx, name, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass1", *config.Value)
+	assert.Equal(t, 1, config.Meta.Version)
+
+	_, err = s.Get(ctx, name, 3)
+	assert.Equal(t, ErrConfigNotFound, err)
+
+	history, err := s.History(ctx, name)
+	assert.Nil(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "pass1", *history[0].Value)
+	assert.Equal(t, 1, history[0].Meta.Version)
+	assert.Equal(t, "pass2", *history[1].Value)
+	assert.True(t, history[1].Meta.Secure)
+
+	_, err = s.History(ctx, ParameterName{ParameterPath: "/test/db", Name: "missing"})
+	assert.Equal(t, ErrConfigNotFound, err)
+
+	// empty description clears the current one, e.g. stale type marker
+	v := "pass2"
+	err = s.Put(ctx, name, Value{Value: &v, Meta: Metadata{Secure: true}})
+	assert.Nil(t, err)
+
+	config, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, config.Meta.Version)
+	assert.Equal(t, "", config.Meta.Description)
+}
+
+func TestSSMStoreLegacyVersions(t *testing.T) {
+	ctx := context.Background()
+
+	fake := newFakeSSM()
+	legacy := &SSMStore{svc: fake, config: SSMStoreConfig{LegacyVersions: true}}
+
+	name := ParameterName{ParameterPath: "/test/db", Name: "password"}
+
+	// simulate parameter which was once deleted and written again, so
+	// legacy and native versions differ
+	for _, v := range []string{"old", "pass1", "pass2"} {
+		v := v
+		err := legacy.Put(ctx, name, Value{Value: &v})
+		assert.Nil(t, err)
+
+		if v == "old" {
+			fake.parameters["/test/db/password"][0].Description = aws.String("0")
+		}
+	}
+
+	assert.Equal(t, "2", *fake.latest("/test/db/password").Description)
+
+	config, err := legacy.Get(ctx, name, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass1", *config.Value)
+	assert.Equal(t, 1, config.Meta.Version)
+
+	description := "not supported"
+	err = legacy.Put(ctx, name, Value{Value: &description, Meta: Metadata{Description: description}})
+	assert.Error(t, err)
+	assert.False(t, KeepsDescription(legacy))
+
+	// without compatibility mode, native versions are used and numeric
+	// descriptions are regular descriptions
+	s := &SSMStore{svc: fake}
+	assert.True(t, KeepsDescription(s))
+
+	config, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass2", *config.Value)
+	assert.Equal(t, 3, config.Meta.Version)
+
+	year := "2024"
+	err = s.Put(ctx, name, Value{Value: &year, Meta: Metadata{Description: year}})
+	assert.Nil(t, err)
+
+	config, err = s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, config.Meta.Version)
+	assert.Equal(t, "2024", config.Meta.Description)
+}
+
+func TestSSMStoreKMSKey(t *testing.T) {
+	ctx := context.Background()
+
+	fake := newFakeSSM()
+	s := &SSMStore{svc: fake, config: SSMStoreConfig{
+		KMSKey: "alias/default",
+		KMSKeyByPrefix: map[string]string{
+			"/prod":    "alias/prod",
+			"/prod/db": "alias/prod-db",
+		},
+	}}
+
+	cases := []struct {
+		name        ParameterName
+		keyID       string
+		expectedKey string
+	}{
+		{ParameterName{ParameterPath: "/dev", Name: "password"}, "", "alias/default"},
+		{ParameterName{ParameterPath: "/prod/api", Name: "token"}, "", "alias/prod"},
+		{ParameterName{ParameterPath: "/prod/db", Name: "password"}, "", "alias/prod-db"},
+		{ParameterName{ParameterPath: "/production", Name: "password"}, "", "alias/default"},
+		{ParameterName{ParameterPath: "/prod/db", Name: "copy"}, "alias/explicit", "alias/explicit"},
+	}
+
+	for _, testCase := range cases {
+		v := "secret"
+		err := s.Put(ctx, testCase.name, Value{Value: &v, Meta: Metadata{Secure: true, KeyID: testCase.keyID}})
+		assert.Nil(t, err)
+
+		config, err := s.Get(ctx, testCase.name, -1)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expectedKey, config.Meta.KeyID)
+	}
+}
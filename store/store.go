@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"errors"
 	"time"
 )
@@ -9,6 +10,14 @@ var (
 	// ErrConfigNotFound is returned if the specified config is not found in the
 	// parameter store.
 	ErrConfigNotFound = errors.New("config not found")
+
+	// ErrValueMissing is returned if the configuration is written without
+	// value.
+	ErrValueMissing = errors.New("config value is missing")
+
+	// ErrStagesNotSupported is returned if version stages are requested from
+	// the store which does not label versions with stages.
+	ErrStagesNotSupported = errors.New("version stages are not supported by the store")
 )
 
 // ParameterName represents full name of the configuration parameter
@@ -33,15 +42,65 @@ type Metadata struct {
 	Key              string
 	Description      string
 	Secure           bool
+	KeyID            string
 	Version          int
 	LastModifiedDate time.Time
 	LastModifiedUser string
 }
 
+// IsThrottlingError reports whether the backend rejected the request because
+// of request rate, so it may succeed when retried later.
+func IsThrottlingError(err error) bool {
+	return isAWSThrottlingError(err) || isVaultThrottlingError(err)
+}
+
+// MetadataKeeper is implemented by stores which do not keep all metadata
+// written with values.
+type MetadataKeeper interface {
+	// KeepsSecure reports whether the secure flag is kept as written
+	KeepsSecure() bool
+	// KeepsDescription reports whether the description is kept
+	KeepsDescription() bool
+}
+
+// KeepsSecure reports whether the store keeps the secure flag as written.
+func KeepsSecure(s Store) bool {
+	if k, ok := s.(MetadataKeeper); ok {
+		return k.KeepsSecure()
+	}
+
+	return true
+}
+
+// KeepsDescription reports whether the store keeps the description.
+func KeepsDescription(s Store) bool {
+	if k, ok := s.(MetadataKeeper); ok {
+		return k.KeepsDescription()
+	}
+
+	return true
+}
+
+// KMSKeyStore is implemented by stores which encrypt secure values with KMS
+// keys.
+type KMSKeyStore interface {
+	// KMSKeyFor returns the key used to encrypt the given secure configuration
+	KMSKeyFor(name ParameterName) string
+}
+
+// StageStore is implemented by stores which label configuration versions
+// with stages, like Secrets Manager does.
+type StageStore interface {
+	// GetStage reads the configuration version labeled with the stage
+	GetStage(ctx context.Context, name ParameterName, stage string) (Value, error)
+}
+
 type Store interface {
-	Put(name ParameterName, value Value) error
-	Get(name ParameterName, version int) (Value, error)
-	List(prefix string, includeValues bool) ([]Value, error)
-	ListRaw(prefix string) ([]RawValue, error)
-	Delete(name ParameterName) error
+	Put(ctx context.Context, name ParameterName, value Value) error
+	Get(ctx context.Context, name ParameterName, version int) (Value, error)
+	List(ctx context.Context, prefix string, includeValues bool) ([]Value, error)
+	ListRaw(ctx context.Context, prefix string) ([]RawValue, error)
+	Delete(ctx context.Context, name ParameterName) error
+	// History returns all versions of the configuration, oldest first.
+	History(ctx context.Context, name ParameterName) ([]Value, error)
 }
@@ -0,0 +1,492 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	vaultAddrEnvVar      = "VAULT_ADDR"
+	vaultTokenEnvVar     = "VAULT_TOKEN"
+	vaultNamespaceEnvVar = "VAULT_NAMESPACE"
+	vaultMountEnvVar     = "SICC_VAULT_MOUNT"
+	vaultRoleIDEnvVar    = "SICC_VAULT_ROLE_ID"
+	vaultSecretIDEnvVar  = "SICC_VAULT_SECRET_ID"
+
+	// DefaultVaultAddr is the address used when none is configured
+	DefaultVaultAddr = "https://127.0.0.1:8200"
+
+	// DefaultVaultMount is the path where KV v2 secrets engine is mounted
+	DefaultVaultMount = "secret"
+
+	vaultClientTimeout = 60 * time.Second
+)
+
+// VaultConfig holds settings used to access Vault
+type VaultConfig struct {
+	Address   string
+	Namespace string
+	Mount     string
+
+	// Token is used directly if set, otherwise AppRole login is performed
+	// using RoleID and SecretID.
+	Token    string
+	RoleID   string
+	SecretID string
+}
+
+// VaultStore implements the Store interface for storing configurations in
+// Vault KV v2 secrets engine. Every configuration is a separate secret, with
+// the value kept under the `value` key.
+type VaultStore struct {
+	config VaultConfig
+	client *http.Client
+	token  string
+}
+
+type vaultSecretData struct {
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Secure      bool   `json:"secure,omitempty"`
+}
+
+type vaultSecretMetadata struct {
+	CreatedTime  time.Time `json:"created_time"`
+	DeletionTime string    `json:"deletion_time"`
+	Destroyed    bool      `json:"destroyed"`
+	Version      int       `json:"version"`
+}
+
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Auth   *vaultAuth      `json:"auth"`
+	Errors []string        `json:"errors"`
+}
+
+type vaultAuth struct {
+	ClientToken string `json:"client_token"`
+}
+
+// vaultError represents error response returned by Vault
+type vaultError struct {
+	StatusCode int
+	Errors     []string
+}
+
+func (e *vaultError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("vault responded with status %d: %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("vault responded with status %d: %s", e.StatusCode, strings.Join(e.Errors, ", "))
+}
+
+// isVaultThrottlingError reports whether Vault rejected the request because
+// of rate limit quota.
+func isVaultThrottlingError(err error) bool {
+	var verr *vaultError
+
+	return errors.As(err, &verr) && verr.StatusCode == http.StatusTooManyRequests
+}
+
+// VaultConfigFromEnv creates VaultConfig from the same environment variables
+// as the Vault CLI uses, plus sicc specific ones for mount and AppRole.
+func VaultConfigFromEnv() VaultConfig {
+	config := VaultConfig{
+		Address:   DefaultVaultAddr,
+		Namespace: os.Getenv(vaultNamespaceEnvVar),
+		Mount:     DefaultVaultMount,
+		Token:     os.Getenv(vaultTokenEnvVar),
+		RoleID:    os.Getenv(vaultRoleIDEnvVar),
+		SecretID:  os.Getenv(vaultSecretIDEnvVar),
+	}
+
+	if addr, ok := os.LookupEnv(vaultAddrEnvVar); ok {
+		config.Address = addr
+	}
+
+	if mount, ok := os.LookupEnv(vaultMountEnvVar); ok {
+		config.Mount = mount
+	}
+
+	return config
+}
+
+// NewVaultStore creates a new VaultStore
+func NewVaultStore(config VaultConfig) (*VaultStore, error) {
+	if config.Mount == "" {
+		config.Mount = DefaultVaultMount
+	}
+
+	s := &VaultStore{
+		config: config,
+		client: &http.Client{Timeout: vaultClientTimeout},
+		token:  config.Token,
+	}
+
+	if s.token == "" {
+		if config.RoleID == "" {
+			return nil, errors.New("vault token or AppRole credentials must be specified")
+		}
+
+		// login is bound only by the client timeout, the same as creating
+		// sessions for other backends
+		if err := s.loginAppRole(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to login to vault: %w", err)
+		}
+	}
+
+	return s, nil
+}
+
+// Put adds a given value to a the system identified by name.
+// If the configuration already exists, then it writes a new version.
+func (s *VaultStore) Put(ctx context.Context, name ParameterName, value Value) error {
+	if value.Value == nil {
+		return ErrValueMissing
+	}
+
+	body := map[string]interface{}{
+		"data": vaultSecretData{
+			Value:       *value.Value,
+			Description: value.Meta.Description,
+			Secure:      value.Meta.Secure,
+		},
+	}
+
+	_, err := s.request(ctx, http.MethodPost, s.dataPath(s.parameterNameToString(name)), nil, body, nil)
+
+	return err
+}
+
+// Get reads a configuration from Vault at a specific version.
+// To grab the latest version, use -1 as the version number.
+func (s *VaultStore) Get(ctx context.Context, name ParameterName, version int) (Value, error) {
+	return s.get(ctx, s.parameterNameToString(name), version)
+}
+
+func (s *VaultStore) List(ctx context.Context, prefix string, includeValues bool) ([]Value, error) {
+	keys, err := s.listKeys(ctx, path.Join("/", prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	values := []Value{}
+
+	for _, key := range keys {
+		value, err := s.get(ctx, key, -1)
+		if err != nil {
+			if errors.Is(err, ErrConfigNotFound) {
+				// only deleted versions remain
+				continue
+			}
+
+			return nil, err
+		}
+
+		if !includeValues {
+			value.Value = nil
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func (s *VaultStore) ListRaw(ctx context.Context, prefix string) ([]RawValue, error) {
+	values, err := s.List(ctx, prefix, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rawValues := make([]RawValue, len(values))
+
+	for i, value := range values {
+		rawValues[i] = RawValue{
+			Value: *value.Value,
+			Key:   value.Meta.Key,
+		}
+	}
+
+	return rawValues, nil
+}
+
+// Delete removes a configuration from Vault. Note this removes all versions
+// of the configuration.
+func (s *VaultStore) Delete(ctx context.Context, name ParameterName) error {
+	key := s.parameterNameToString(name)
+
+	// first read to ensure parameter present
+	if _, err := s.get(ctx, key, -1); err != nil {
+		return err
+	}
+
+	_, err := s.request(ctx, http.MethodDelete, s.metadataPath(key), nil, nil, nil)
+
+	return err
+}
+
+// History returns all versions of the configuration which were not deleted
+// or destroyed, oldest first.
+func (s *VaultStore) History(ctx context.Context, name ParameterName) ([]Value, error) {
+	key := s.parameterNameToString(name)
+
+	var metadata struct {
+		Versions map[string]vaultSecretMetadata `json:"versions"`
+	}
+
+	found, err := s.request(ctx, http.MethodGet, s.metadataPath(key), nil, nil, &metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, ErrConfigNotFound
+	}
+
+	versions := []int{}
+
+	for k, m := range metadata.Versions {
+		if m.Destroyed || m.DeletionTime != "" {
+			continue
+		}
+
+		version, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version `%s` of `%s`: %w", k, key, err)
+		}
+
+		versions = append(versions, version)
+	}
+
+	sort.Ints(versions)
+
+	values := []Value{}
+
+	for _, version := range versions {
+		value, err := s.get(ctx, key, version)
+		if err == ErrConfigNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	if len(values) == 0 {
+		return nil, ErrConfigNotFound
+	}
+
+	return values, nil
+}
+
+func (s *VaultStore) parameterNameToString(name ParameterName) string {
+	return path.Join([]string{"/", name.ParameterPath, name.Name}...)
+}
+
+func (s *VaultStore) dataPath(key string) string {
+	return path.Join("/v1", s.config.Mount, "data", key)
+}
+
+func (s *VaultStore) metadataPath(key string) string {
+	return path.Join("/v1", s.config.Mount, "metadata", key)
+}
+
+func (s *VaultStore) get(ctx context.Context, key string, version int) (Value, error) {
+	var query url.Values
+
+	if version != -1 {
+		query = url.Values{"version": []string{strconv.Itoa(version)}}
+	}
+
+	var secret struct {
+		Data     *vaultSecretData    `json:"data"`
+		Metadata vaultSecretMetadata `json:"metadata"`
+	}
+
+	found, err := s.request(ctx, http.MethodGet, s.dataPath(key), query, nil, &secret)
+	if err != nil {
+		return Value{}, err
+	}
+
+	// deleted and destroyed versions have no data
+	if !found || secret.Data == nil {
+		return Value{}, ErrConfigNotFound
+	}
+
+	return Value{
+		Value: &secret.Data.Value,
+		Meta: Metadata{
+			Key:              key,
+			Description:      secret.Data.Description,
+			Secure:           secret.Data.Secure,
+			Version:          secret.Metadata.Version,
+			LastModifiedDate: secret.Metadata.CreatedTime,
+		},
+	}, nil
+}
+
+// listKeys recursively lists all configuration keys under the prefix.
+func (s *VaultStore) listKeys(ctx context.Context, prefix string) ([]string, error) {
+	var list struct {
+		Keys []string `json:"keys"`
+	}
+
+	found, err := s.request(ctx, "LIST", s.metadataPath(prefix), nil, nil, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return []string{}, nil
+	}
+
+	keys := []string{}
+
+	for _, k := range list.Keys {
+		key := path.Join(prefix, k)
+
+		if strings.HasSuffix(k, "/") {
+			subKeys, err := s.listKeys(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, subKeys...)
+
+			continue
+		}
+
+		if !validPathKeyFormat.MatchString(key) {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (s *VaultStore) loginAppRole(ctx context.Context) error {
+	body := map[string]string{
+		"role_id":   s.config.RoleID,
+		"secret_id": s.config.SecretID,
+	}
+
+	resp, err := s.do(ctx, http.MethodPost, "/v1/auth/approle/login", nil, body)
+	if err != nil {
+		return err
+	}
+
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return errors.New("vault login response does not contain token")
+	}
+
+	s.token = resp.Auth.ClientToken
+
+	return nil
+}
+
+// request sends request to Vault and decodes `data` field of the response
+// into out. It returns false if Vault responded with not found.
+func (s *VaultStore) request(ctx context.Context, method, urlPath string, query url.Values, body, out interface{}) (bool, error) {
+	resp, err := s.do(ctx, method, urlPath, query, body)
+	if err != nil {
+		var vaultErr *vaultError
+		if errors.As(err, &vaultErr) && vaultErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	if out != nil && len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			return false, fmt.Errorf("failed to decode vault response: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+func (s *VaultStore) do(ctx context.Context, method, urlPath string, query url.Values, body interface{}) (*vaultResponse, error) {
+	u, err := url.Parse(strings.TrimSuffix(s.config.Address, "/") + urlPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault address: %w", err)
+	}
+
+	u.RawQuery = query.Encode()
+
+	var reqBody io.Reader
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.token != "" {
+		req.Header.Set("X-Vault-Token", s.token)
+	}
+
+	if s.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.config.Namespace)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &vaultResponse{}
+
+	if resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil && err != io.EOF {
+			// error responses may come from a proxy in front of Vault, with
+			// a body which is not JSON
+			if resp.StatusCode >= http.StatusBadRequest {
+				return nil, &vaultError{StatusCode: resp.StatusCode}
+			}
+
+			return nil, fmt.Errorf("failed to decode vault response: %w", err)
+		}
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, &vaultError{StatusCode: resp.StatusCode, Errors: result.Errors}
+	}
+
+	return result, nil
+}
+
+// Check the interfaces are satisfied
+var (
+	_ Store = &VaultStore{}
+)
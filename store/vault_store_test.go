@@ -0,0 +1,272 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault is a minimal stand-in of Vault KV v2 HTTP API.
+type fakeVault struct {
+	token    string
+	roleID   string
+	secretID string
+
+	mu      sync.Mutex
+	secrets map[string][]map[string]interface{}
+}
+
+func newFakeVault() *fakeVault {
+	return &fakeVault{
+		token:    "s.token",
+		roleID:   "role",
+		secretID: "secret",
+		secrets:  map[string][]map[string]interface{}{},
+	}
+}
+
+//nolint:funlen
+func (v *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var body map[string]string
+
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if body["role_id"] != v.roleID || body["secret_id"] != v.secretID {
+			v.respond(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"invalid role or secret ID"}})
+			return
+		}
+
+		v.respond(w, http.StatusOK, map[string]interface{}{"auth": map[string]string{"client_token": v.token}})
+
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != v.token {
+		v.respond(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/data/"):
+		key := strings.TrimPrefix(r.URL.Path, "/v1/secret/data")
+
+		switch r.Method {
+		case http.MethodGet:
+			versions := v.secrets[key]
+			if len(versions) == 0 {
+				v.respond(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+				return
+			}
+
+			version := len(versions)
+			if q := r.URL.Query().Get("version"); q != "" {
+				version, _ = strconv.Atoi(q)
+			}
+
+			if version < 1 || version > len(versions) {
+				v.respond(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+				return
+			}
+
+			v.respond(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+				"data": versions[version-1],
+				"metadata": map[string]interface{}{
+					"created_time": time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+					"version":      version,
+				},
+			}})
+		case http.MethodPost:
+			var body map[string]map[string]interface{}
+
+			_ = json.NewDecoder(r.Body).Decode(&body)
+
+			v.secrets[key] = append(v.secrets[key], body["data"])
+
+			v.respond(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"version": len(v.secrets[key])}})
+		}
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/metadata"):
+		key := strings.TrimPrefix(r.URL.Path, "/v1/secret/metadata")
+
+		switch r.Method {
+		case "LIST":
+			prefix := strings.TrimSuffix(key, "/") + "/"
+			keys := map[string]struct{}{}
+
+			for k := range v.secrets {
+				if strings.HasPrefix(k, prefix) {
+					rest := strings.TrimPrefix(k, prefix)
+					if i := strings.Index(rest, "/"); i != -1 {
+						rest = rest[:i+1]
+					}
+
+					keys[rest] = struct{}{}
+				}
+			}
+
+			if len(keys) == 0 {
+				v.respond(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+				return
+			}
+
+			list := []string{}
+			for k := range keys {
+				list = append(list, k)
+			}
+
+			sort.Strings(list)
+
+			v.respond(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"keys": list}})
+		case http.MethodGet:
+			if len(v.secrets[key]) == 0 {
+				v.respond(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+				return
+			}
+
+			versions := map[string]interface{}{}
+			for i := range v.secrets[key] {
+				versions[strconv.Itoa(i+1)] = map[string]interface{}{
+					"created_time":  time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+					"deletion_time": "",
+					"destroyed":     false,
+				}
+			}
+
+			v.respond(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"versions": versions}})
+		case http.MethodDelete:
+			delete(v.secrets, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		v.respond(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func (v *fakeVault) respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+//nolint:funlen
+func TestVaultStore(t *testing.T) {
+	ctx := context.Background()
+
+	fake := newFakeVault()
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	t.Run("invalid AppRole", func(t *testing.T) {
+		_, err := NewVaultStore(VaultConfig{Address: server.URL, RoleID: "role", SecretID: "wrong"})
+		assert.Error(t, err)
+	})
+
+	s, err := NewVaultStore(VaultConfig{Address: server.URL, RoleID: "role", SecretID: "secret"})
+	assert.Nil(t, err)
+
+	name := ParameterName{ParameterPath: "/test/db", Name: "password"}
+
+	_, err = s.Get(ctx, name, -1)
+	assert.Equal(t, ErrConfigNotFound, err)
+
+	err = s.Put(ctx, name, Value{Meta: Metadata{Secure: true}})
+	assert.Equal(t, ErrValueMissing, err)
+
+	for _, v := range []string{"pass1", "pass2"} {
+		v := v
+		err = s.Put(ctx, name, Value{Value: &v, Meta: Metadata{Secure: true}})
+		assert.Nil(t, err)
+	}
+
+	username := "admin"
+	err = s.Put(ctx, ParameterName{ParameterPath: "/test/db", Name: "username"}, Value{Value: &username})
+	assert.Nil(t, err)
+
+	host := "localhost"
+	err = s.Put(ctx, ParameterName{ParameterPath: "/test", Name: "host"}, Value{Value: &host})
+	assert.Nil(t, err)
+
+	config, err := s.Get(ctx, name, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass2", *config.Value)
+	assert.Equal(t, "/test/db/password", config.Meta.Key)
+	assert.Equal(t, 2, config.Meta.Version)
+	assert.True(t, config.Meta.Secure)
+
+	config, err = s.Get(ctx, name, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "pass1", *config.Value)
+
+	_, err = s.Get(ctx, name, 3)
+	assert.Equal(t, ErrConfigNotFound, err)
+
+	history, err := s.History(ctx, name)
+	assert.Nil(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "pass1", *history[0].Value)
+	assert.Equal(t, 2, history[1].Meta.Version)
+
+	rawValues, err := s.ListRaw(ctx, "/test")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []RawValue{
+		{Value: "pass2", Key: "/test/db/password"},
+		{Value: "admin", Key: "/test/db/username"},
+		{Value: "localhost", Key: "/test/host"},
+	}, rawValues)
+
+	configs, err := s.List(ctx, "/test/db", false)
+	assert.Nil(t, err)
+	assert.Len(t, configs, 2)
+	assert.Nil(t, configs[0].Value)
+
+	err = s.Delete(ctx, name)
+	assert.Nil(t, err)
+
+	err = s.Delete(ctx, name)
+	assert.Equal(t, ErrConfigNotFound, err)
+
+	rawValues, err = s.ListRaw(ctx, "/other")
+	assert.Nil(t, err)
+	assert.Empty(t, rawValues)
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := s.ListRaw(ctx, "/test")
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+
+	t.Run("error response without JSON body", func(t *testing.T) {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>Bad Gateway</html>"))
+		}))
+		defer proxy.Close()
+
+		s, err := NewVaultStore(VaultConfig{Address: proxy.URL, Token: "s.token"})
+		assert.Nil(t, err)
+
+		_, err = s.Get(ctx, name, -1)
+
+		var verr *vaultError
+		assert.True(t, errors.As(err, &verr))
+		assert.Equal(t, http.StatusBadGateway, verr.StatusCode)
+	})
+}